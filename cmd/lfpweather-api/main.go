@@ -5,18 +5,29 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/alpineworks/ootel"
 	"github.com/gorilla/mux"
+	"github.com/michaelpeterswa/lfpweather-api/internal/almanac"
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
 	"github.com/michaelpeterswa/lfpweather-api/internal/config"
+	"github.com/michaelpeterswa/lfpweather-api/internal/derived"
+	"github.com/michaelpeterswa/lfpweather-api/internal/downsample"
 	"github.com/michaelpeterswa/lfpweather-api/internal/dragonfly"
 	"github.com/michaelpeterswa/lfpweather-api/internal/handlers"
 	"github.com/michaelpeterswa/lfpweather-api/internal/logging"
 	"github.com/michaelpeterswa/lfpweather-api/internal/middleware"
+	"github.com/michaelpeterswa/lfpweather-api/internal/rpc"
+	"github.com/michaelpeterswa/lfpweather-api/internal/stream"
 	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
 	"github.com/michaelpeterswa/lfpweather-api/pkg/electricitymaps"
+	lfpweatherv1 "github.com/michaelpeterswa/lfpweather-api/pkg/proto/lfpweather/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
@@ -76,7 +87,13 @@ func main() {
 		os.Exit(1)
 	}
 
-	timescaleClient, err := timescale.NewTimescaleClient(ctx, c.TimescaleConnString, timescale.WithDragonflyClient(dragonflyClient))
+	stationLocation, err := time.LoadLocation(c.StationTimezone)
+	if err != nil {
+		slog.Error("could not load station timezone", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	timescaleClient, err := timescale.NewTimescaleClient(ctx, c.TimescaleConnString, timescale.WithDragonflyClient(dragonflyClient), timescale.WithRollups(c.RollupsEnabled), timescale.WithRollupLocation(stationLocation))
 	if err != nil {
 		slog.Error("could not create timescale client", slog.String("error", err.Error()))
 		os.Exit(1)
@@ -98,89 +115,146 @@ func main() {
 
 	electricityMapsHandler := handlers.NewElectricityMapsHandler(electricityMapsClient)
 
-	weatherHandler := handlers.NewWeatherHandler(timescaleClient)
+	devices, err := catalog.ParseDevices(c.AirGradientDevices)
+	if err != nil {
+		slog.Error("could not parse airgradient devices", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	derivedClient := derived.NewDerivedClient(timescaleClient)
+
+	downsampleClient := downsample.NewDownsampleClient(timescaleClient)
+
+	weatherHandler := handlers.NewWeatherHandler(timescaleClient, derivedClient, downsampleClient, devices, stationLocation)
 
 	birdnetHandler := handlers.NewBirdnetHandler(timescaleClient)
 
+	catalogHandler := handlers.NewCatalogHandler(devices)
+
+	almanacClient := almanac.NewAlmanacClient(timescaleClient, almanac.WithCacheDuration(c.RecordsCacheDuration))
+
+	climateHandler := handlers.NewClimateHandler(timescaleClient, almanacClient, devices, stationLocation)
+
+	exportHandler := handlers.NewExportHandler(timescaleClient)
+
+	streamHub := stream.NewHub(timescaleClient, devices,
+		stream.WithPollInterval(c.StreamPollInterval),
+		stream.WithListen(c.StreamListen),
+	)
+	go streamHub.Run(ctx)
+
+	streamHandler := handlers.NewStreamHandler(streamHub, devices)
+
+	socketHandler := handlers.NewSocketHandler(timescaleClient, streamHub, devices)
+
+	graphQLHandler, err := handlers.NewGraphQLHandler(weatherHandler, electricityMapsClient)
+	if err != nil {
+		slog.Error("could not create graphql handler", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
 	r := mux.NewRouter()
 	apiRouter := r.PathPrefix("/api").Subrouter()
 	v1Subrouter := apiRouter.PathPrefix("/v1").Subrouter()
 
-	// last data
-	v1Subrouter.HandleFunc("/temperature/last", weatherHandler.GetTemperatureLast).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/humidity/last", weatherHandler.GetHumidityLast).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/pressure/last", weatherHandler.GetPressureLast).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/solar_radiation/last", weatherHandler.GetSolarRadiationLast).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/wind_speed/last", weatherHandler.GetWindSpeedHighLast10MinLast).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/24h_rain/last", weatherHandler.GetRainLast24hLast).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/uv_index/last", weatherHandler.GetUVIndexLast).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/aqi/last", weatherHandler.GetAQILast).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/co2/last", weatherHandler.GetCo2Last).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/nox_index/last", weatherHandler.GetNoxIndexLast).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/tvoc_index/last", weatherHandler.GetTvocIndexLast).Methods(http.MethodGet)
+	v1Subrouter.HandleFunc("/metrics", catalogHandler.GetMetrics).Methods(http.MethodGet)
+	v1Subrouter.HandleFunc("/devices", catalogHandler.GetDevices).Methods(http.MethodGet)
+
 	// electricitymaps
 	v1Subrouter.HandleFunc("/electricitymaps/power_breakdown/latest", electricityMapsHandler.GetPowerBreakdownLatest).Methods(http.MethodGet)
 
-	//12h data
-	v1Subrouter.HandleFunc("/temperature/12h", weatherHandler.GetTemperature12h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/humidity/12h", weatherHandler.GetHumidity12h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/pressure/12h", weatherHandler.GetPressure12h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/solar_radiation/12h", weatherHandler.GetSolarRadiation12h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/wind_speed/12h", weatherHandler.GetWindSpeedLast12h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/rain_rate/12h", weatherHandler.GetRainRateLast12h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/uv_index/12h", weatherHandler.GetUVIndex12h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/aqi/12h", weatherHandler.GetAQI12h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/co2/12h", weatherHandler.GetCo212h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/nox_index/12h", weatherHandler.GetNoxIndex12h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/tvoc_index/12h", weatherHandler.GetTvocIndex12h).Methods(http.MethodGet)
-
-	// 24h data
-	v1Subrouter.HandleFunc("/temperature/24h", weatherHandler.GetTemperature24h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/humidity/24h", weatherHandler.GetHumidity24h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/pressure/24h", weatherHandler.GetPressure24h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/solar_radiation/24h", weatherHandler.GetSolarRadiation24h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/wind_speed/24h", weatherHandler.GetWindSpeedLast24h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/rain_rate/24h", weatherHandler.GetRainRateLast24h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/uv_index/24h", weatherHandler.GetUVIndex24h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/aqi/24h", weatherHandler.GetAQI24h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/co2/24h", weatherHandler.GetCo224h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/nox_index/24h", weatherHandler.GetNoxIndex24h).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/tvoc_index/24h", weatherHandler.GetTvocIndex24h).Methods(http.MethodGet)
+	// birdnet
 	v1Subrouter.HandleFunc("/birdnet/24h", birdnetHandler.GetBirdCount24h).Methods(http.MethodGet)
 
-	// 7d data
-	v1Subrouter.HandleFunc("/temperature/7d", weatherHandler.GetTemperature7d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/humidity/7d", weatherHandler.GetHumidity7d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/pressure/7d", weatherHandler.GetPressure7d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/solar_radiation/7d", weatherHandler.GetSolarRadiation7d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/wind_speed/7d", weatherHandler.GetWindSpeedLast7d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/rain_rate/7d", weatherHandler.GetRainRateLast7d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/uv_index/7d", weatherHandler.GetUVIndex7d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/aqi/7d", weatherHandler.GetAQI7d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/co2/7d", weatherHandler.GetCo27d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/nox_index/7d", weatherHandler.GetNoxIndex7d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/tvoc_index/7d", weatherHandler.GetTvocIndex7d).Methods(http.MethodGet)
-
-	// 30d data
-	v1Subrouter.HandleFunc("/temperature/30d", weatherHandler.GetTemperature30d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/humidity/30d", weatherHandler.GetHumidity30d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/pressure/30d", weatherHandler.GetPressure30d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/solar_radiation/30d", weatherHandler.GetSolarRadiation30d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/wind_speed/30d", weatherHandler.GetWindSpeedLast30d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/rain_rate/30d", weatherHandler.GetRainRateLast30d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/uv_index/30d", weatherHandler.GetUVIndex30d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/aqi/30d", weatherHandler.GetAQI30d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/co2/30d", weatherHandler.GetCo230d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/nox_index/30d", weatherHandler.GetNoxIndex30d).Methods(http.MethodGet)
-	v1Subrouter.HandleFunc("/tvoc_index/30d", weatherHandler.GetTvocIndex30d).Methods(http.MethodGet)
+	// current conditions
+	v1Subrouter.HandleFunc("/current", weatherHandler.GetCurrent).Methods(http.MethodGet)
+
+	// batch queries
+	v1Subrouter.HandleFunc("/query", weatherHandler.PostQuery).Methods(http.MethodPost)
+
+	// wind
+	v1Subrouter.HandleFunc("/wind/rose", weatherHandler.GetWindRose).Methods(http.MethodGet)
+
+	// rain
+	v1Subrouter.HandleFunc("/rain/daily", climateHandler.GetRainDaily).Methods(http.MethodGet)
+	v1Subrouter.HandleFunc("/rain/monthly", climateHandler.GetRainMonthly).Methods(http.MethodGet)
+	v1Subrouter.HandleFunc("/rain/total", climateHandler.GetRainTotal).Methods(http.MethodGet)
+	v1Subrouter.HandleFunc("/rain/summary", climateHandler.GetRainSummary).Methods(http.MethodGet)
+
+	// climate
+	v1Subrouter.HandleFunc("/climate/daily", climateHandler.GetClimateDaily).Methods(http.MethodGet)
+	v1Subrouter.HandleFunc("/records", climateHandler.GetRecords).Methods(http.MethodGet)
+	v1Subrouter.HandleFunc("/almanac/{month:[0-9]{1,2}}-{day:[0-9]{1,2}}", climateHandler.GetAlmanacDay).Methods(http.MethodGet)
+
+	// graphql
+	v1Subrouter.HandleFunc("/graphql", graphQLHandler.ServeGraphQL).Methods(http.MethodGet, http.MethodPost)
+
+	// live updates
+	v1Subrouter.HandleFunc("/stream", streamHandler.GetStream).Methods(http.MethodGet)
+	v1Subrouter.HandleFunc("/socket", socketHandler.GetSocket).Methods(http.MethodGet)
+
+	// raw exports
+	v1Subrouter.HandleFunc("/export/{table}", exportHandler.GetExport).Methods(http.MethodGet)
+
+	// weather metrics, generated from the catalog
+	for _, metric := range catalog.Metrics {
+		if metric.HasLast() {
+			v1Subrouter.HandleFunc(metric.LastPath(), weatherHandler.GetColumnLast(metric)).Methods(http.MethodGet)
+		}
+
+		if metric.HasRange() {
+			for _, window := range catalog.Windows {
+				v1Subrouter.HandleFunc(metric.WindowPath(window), weatherHandler.GetColumnWindow(metric, window)).Methods(http.MethodGet)
+			}
+		}
+	}
+
+	// arbitrary range data
+	v1Subrouter.HandleFunc("/{metric}", weatherHandler.GetColumnRange).Methods(http.MethodGet)
+
+	var grpcOpts []grpc.ServerOption
 
 	if c.AuthenticationEnabled {
 		authenticationMiddleware := middleware.NewAuthenticationMiddlewareClient(
 			middleware.WithAPIKeys(c.APIKeys),
 		)
 		apiRouter.Use(authenticationMiddleware.AuthenticationMiddleware)
+
+		grpcOpts = append(grpcOpts,
+			grpc.UnaryInterceptor(authenticationMiddleware.UnaryServerInterceptor),
+			grpc.StreamInterceptor(authenticationMiddleware.StreamServerInterceptor),
+		)
 	}
 
+	grpcServer := grpc.NewServer(grpcOpts...)
+	lfpweatherv1.RegisterWeatherServiceServer(grpcServer, rpc.NewWeatherServer(timescaleClient, derivedClient, streamHub, devices, stationLocation))
+	reflection.Register(grpcServer)
+
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", c.GRPCPort))
+	if err != nil {
+		slog.Error("could not listen for grpc", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	go func() {
+		err := grpcServer.Serve(grpcListener)
+		if err != nil {
+			slog.Error("could not start grpc server", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}()
+
+	openAPIHandler, err := handlers.NewOpenAPIHandler(r, "/api/v1", c.AuthenticationEnabled)
+	if err != nil {
+		slog.Error("could not create openapi handler", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// documentation, outside of /api so it is readable without an api key
+	r.HandleFunc("/openapi.json", openAPIHandler.GetOpenAPI).Methods(http.MethodGet)
+	r.HandleFunc("/docs", openAPIHandler.GetDocs).Methods(http.MethodGet)
+	r.PathPrefix("/docs/").Handler(openAPIHandler.GetDocsAssets()).Methods(http.MethodGet)
+
 	http.Handle("/", r)
 
 	err = http.ListenAndServe(fmt.Sprintf(":%d", c.Port), nil)
@@ -0,0 +1,89 @@
+// lfpweather-rollups manages the continuous aggregates that answer coarse
+// ranged queries, see timescale.Rollups.
+//
+//	lfpweather-rollups create   creates the views and their refresh policies
+//	lfpweather-rollups refresh  materializes the full history of the views
+//	lfpweather-rollups drop     drops the views, queries fall back to raw readings
+//
+// Daily views are bucketed by the wall clock of STATION_TIMEZONE, which must
+// match the api's. After changing it drop, create and refresh the views.
+//
+// It also manages the triggers that notify live updates of new readings, see
+// timescale.ReadingsChannel.
+//
+//	lfpweather-rollups notify-create  creates the triggers
+//	lfpweather-rollups notify-drop    drops the triggers, live updates only poll
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/michaelpeterswa/lfpweather-api/internal/logging"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+)
+
+type config struct {
+	LogLevel            string `env:"LOG_LEVEL" envDefault:"info"`
+	TimescaleConnString string `env:"TIMESCALE_CONN_STRING,required"`
+	StationTimezone     string `env:"STATION_TIMEZONE" envDefault:"America/Los_Angeles"`
+}
+
+func main() {
+	var c config
+
+	err := env.Parse(&c)
+	if err != nil {
+		log.Fatalf("could not parse config: %s", err)
+	}
+
+	slogLevel, err := logging.LogLevelToSlogLevel(c.LogLevel)
+	if err != nil {
+		log.Fatalf("could not convert log level: %s", err)
+	}
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slogLevel,
+	})))
+
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s create|refresh|drop|notify-create|notify-drop\n", os.Args[0])
+		os.Exit(2)
+	}
+
+	stationLocation, err := time.LoadLocation(c.StationTimezone)
+	if err != nil {
+		slog.Error("could not load station timezone", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	timescaleClient, err := timescale.NewTimescaleClient(ctx, c.TimescaleConnString, timescale.WithRollupLocation(stationLocation))
+	if err != nil {
+		slog.Error("could not create timescale client", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer timescaleClient.Close()
+
+	if action, ok := strings.CutPrefix(os.Args[1], "notify-"); ok {
+		err = timescaleClient.ManageNotify(ctx, action)
+		if err != nil {
+			slog.Error("could not manage notify triggers", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		return
+	}
+
+	err = timescaleClient.ManageRollups(ctx, os.Args[1])
+	if err != nil {
+		slog.Error("could not manage rollups", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
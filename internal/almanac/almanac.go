@@ -0,0 +1,182 @@
+package almanac
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrInvalidDay = errors.New("invalid calendar day")
+
+// GetRecordsResponse are the all time and monthly records.
+type GetRecordsResponse struct {
+	AllTime Records          `json:"all_time"`
+	Monthly []MonthlyRecords `json:"monthly"`
+}
+
+// GetDayResponse is a calendar day across all recorded years, with its
+// records and average high and low.
+type GetDayResponse struct {
+	Month       int                       `json:"month"`
+	Day         int                       `json:"day"`
+	Years       []timescale.DailyExtremes `json:"years"`
+	Records     Records                   `json:"records"`
+	AverageHigh *float64                  `json:"average_high"`
+	AverageLow  *float64                  `json:"average_low"`
+}
+
+// AlmanacClient computes records and almanac days from the full history.
+// Results rarely change and are cached for much longer than other results.
+type AlmanacClient struct {
+	timescaleClient *timescale.TimescaleClient
+	cacheDuration   time.Duration
+}
+
+type AlmanacClientOption func(*AlmanacClient)
+
+func NewAlmanacClient(timescaleClient *timescale.TimescaleClient, opts ...AlmanacClientOption) *AlmanacClient {
+	c := &AlmanacClient{
+		timescaleClient: timescaleClient,
+		cacheDuration:   24 * time.Hour,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func WithCacheDuration(d time.Duration) AlmanacClientOption {
+	return func(c *AlmanacClient) {
+		c.cacheDuration = d
+	}
+}
+
+func cacheKey(prefix string, kind string, tp timescale.GetDailyExtremesTemplateParameters) string {
+	s := fmt.Sprintf("%s-%s-%d-%d", strings.ReplaceAll(tp.Location.String(), " ", ""), tp.SerialNumber, tp.Month, tp.Day)
+	return fmt.Sprintf("%s-almanac-%s-%s", prefix, kind, strconv.FormatUint(xxhash.Sum64String(s), 16))
+}
+
+// cached serves kind from the cache, or computes it and caches it.
+func cached[T any](ctx context.Context, c *AlmanacClient, kind string, tp timescale.GetDailyExtremesTemplateParameters, compute func([]timescale.DailyExtremes) T) (T, error) {
+	dfly := c.timescaleClient.Dfly
+
+	if dfly != nil {
+		res, err := dfly.GetClient().Get(ctx, cacheKey(dfly.KeyPrefix, kind, tp)).Result()
+		if err == nil {
+			var v T
+			err := json.Unmarshal([]byte(res), &v)
+			if err != nil {
+				slog.Error("failed to unmarshal from dragonfly", slog.String("error", err.Error()))
+			}
+			return v, nil
+		} else if !errors.Is(err, redis.Nil) {
+			slog.Error("failed to get from dragonfly", slog.String("error", err.Error()))
+		}
+	}
+
+	days, err := c.timescaleClient.GetDailyExtremes(ctx, tp)
+	if err != nil {
+		var v T
+		return v, fmt.Errorf("failed to get daily extremes: %w", err)
+	}
+
+	v := compute(days)
+
+	if dfly != nil {
+		vJSON, err := json.Marshal(v)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := dfly.GetClient().Set(ctx, cacheKey(dfly.KeyPrefix, kind, tp), vJSON, c.cacheDuration).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// GetRecords returns the all time and monthly records, with air quality
+// records for the device with serialNumber.
+func (c *AlmanacClient) GetRecords(ctx context.Context, location *time.Location, serialNumber string) (GetRecordsResponse, error) {
+	tp := timescale.GetDailyExtremesTemplateParameters{
+		Location:     location,
+		SerialNumber: serialNumber,
+	}
+
+	return cached(ctx, c, "records", tp, func(days []timescale.DailyExtremes) GetRecordsResponse {
+		return GetRecordsResponse{
+			AllTime: NewRecords(days),
+			Monthly: NewMonthlyRecords(days),
+		}
+	})
+}
+
+// GetDay returns a calendar day across all recorded years.
+func (c *AlmanacClient) GetDay(ctx context.Context, location *time.Location, serialNumber string, month int, day int) (GetDayResponse, error) {
+	if !ValidDay(month, day) {
+		return GetDayResponse{}, fmt.Errorf("%w: %d-%d", ErrInvalidDay, month, day)
+	}
+
+	tp := timescale.GetDailyExtremesTemplateParameters{
+		Location:     location,
+		Month:        month,
+		Day:          day,
+		SerialNumber: serialNumber,
+	}
+
+	return cached(ctx, c, "day", tp, func(days []timescale.DailyExtremes) GetDayResponse {
+		return GetDayResponse{
+			Month:       month,
+			Day:         day,
+			Years:       days,
+			Records:     NewRecords(days),
+			AverageHigh: average(days, func(d timescale.DailyExtremes) *float64 { return d.HighTemperature }),
+			AverageLow:  average(days, func(d timescale.DailyExtremes) *float64 { return d.LowTemperature }),
+		}
+	})
+}
+
+// ValidDay reports whether month and day name a day that exists in some year,
+// February 29 included.
+func ValidDay(month int, day int) bool {
+	if month < 1 || month > 12 || day < 1 {
+		return false
+	}
+
+	// 2024 is a leap year
+	return day <= time.Date(2024, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func average(days []timescale.DailyExtremes, value func(timescale.DailyExtremes) *float64) *float64 {
+	var (
+		sum float64
+		n   int
+	)
+
+	for _, day := range days {
+		if v := value(day); v != nil {
+			sum += *v
+			n++
+		}
+	}
+
+	if n == 0 {
+		return nil
+	}
+
+	avg := sum / float64(n)
+	return &avg
+}
@@ -0,0 +1,93 @@
+package almanac
+
+import (
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+)
+
+// Record is an extreme value and the day it happened. Time is when during the
+// day it was reached, and is unset for daily totals.
+type Record struct {
+	Value float64    `json:"value"`
+	Date  string     `json:"date"`
+	Time  *time.Time `json:"time,omitempty"`
+}
+
+// Records are the extremes over a set of days, nil when none of the days have
+// readings of them.
+type Records struct {
+	HighestTemperature *Record `json:"highest_temperature"`
+	LowestTemperature  *Record `json:"lowest_temperature"`
+	MaxGust            *Record `json:"max_gust"`
+	WettestDay         *Record `json:"wettest_day"`
+	MaxAQI             *Record `json:"max_aqi"`
+}
+
+// MonthlyRecords are the records of a calendar month across all years.
+type MonthlyRecords struct {
+	Month int    `json:"month"`
+	Name  string `json:"name"`
+	Records
+}
+
+// keep replaces r with the value when r is unset or the value beats it.
+// Ties keep the earliest record.
+func keep(r **Record, date string, value *float64, t *time.Time, beats func(a, b float64) bool) {
+	if value == nil {
+		return
+	}
+
+	if *r != nil && !beats(*value, (*r).Value) {
+		return
+	}
+
+	*r = &Record{Value: *value, Date: date, Time: t}
+}
+
+func higher(a, b float64) bool { return a > b }
+func lower(a, b float64) bool  { return a < b }
+
+// add updates the records with the extremes of a day.
+func (r *Records) add(day timescale.DailyExtremes) {
+	keep(&r.HighestTemperature, day.Date, day.HighTemperature, day.HighTemperatureTime, higher)
+	keep(&r.LowestTemperature, day.Date, day.LowTemperature, day.LowTemperatureTime, lower)
+	keep(&r.MaxGust, day.Date, day.MaxGust, day.MaxGustTime, higher)
+	keep(&r.MaxAQI, day.Date, day.MaxAQI, day.MaxAQITime, higher)
+
+	// a dry day is not a record
+	if day.Rain != nil && *day.Rain > 0 {
+		keep(&r.WettestDay, day.Date, day.Rain, nil, higher)
+	}
+}
+
+// NewRecords computes the records over days.
+func NewRecords(days []timescale.DailyExtremes) Records {
+	var records Records
+	for _, day := range days {
+		records.add(day)
+	}
+
+	return records
+}
+
+// NewMonthlyRecords computes the records of every calendar month over days,
+// whose dates are formatted as time.DateOnly.
+func NewMonthlyRecords(days []timescale.DailyExtremes) []MonthlyRecords {
+	monthly := make([]MonthlyRecords, 12)
+	for i := range monthly {
+		monthly[i].Month = i + 1
+		monthly[i].Name = time.Month(i + 1).String()
+	}
+
+	for _, day := range days {
+		date, err := time.Parse(time.DateOnly, day.Date)
+		if err != nil {
+			continue
+		}
+
+		monthly[date.Month()-1].add(day)
+	}
+
+	return monthly
+}
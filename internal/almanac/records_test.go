@@ -0,0 +1,83 @@
+package almanac_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/almanac"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+)
+
+func f(v float64) *float64 { return &v }
+
+func TestNewRecords(t *testing.T) {
+	days := []timescale.DailyExtremes{
+		{Date: "2024-01-12", HighTemperature: f(35), LowTemperature: f(18), MaxGust: f(40), Rain: f(0.4)},
+		{Date: "2024-07-30", HighTemperature: f(98), LowTemperature: f(61), MaxGust: f(12), Rain: f(0)},
+		{Date: "2025-07-08", HighTemperature: f(98), LowTemperature: f(58), Rain: f(0)},
+		{Date: "2025-11-19", HighTemperature: f(52), LowTemperature: f(44), MaxGust: f(52), Rain: f(2.1), MaxAQI: f(12)},
+	}
+
+	records := almanac.NewRecords(days)
+
+	tests := []struct {
+		name   string
+		record *almanac.Record
+		value  float64
+		date   string
+	}{
+		{name: "Highest temperature keeps the first tie", record: records.HighestTemperature, value: 98, date: "2024-07-30"},
+		{name: "Lowest temperature", record: records.LowestTemperature, value: 18, date: "2024-01-12"},
+		{name: "Max gust", record: records.MaxGust, value: 52, date: "2025-11-19"},
+		{name: "Wettest day", record: records.WettestDay, value: 2.1, date: "2025-11-19"},
+		{name: "Max aqi", record: records.MaxAQI, value: 12, date: "2025-11-19"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.record == nil {
+				t.Fatal("expected a record")
+			}
+			if tt.record.Value != tt.value || tt.record.Date != tt.date {
+				t.Errorf("expected %v on %s, got %v on %s", tt.value, tt.date, tt.record.Value, tt.record.Date)
+			}
+		})
+	}
+
+	monthly := almanac.NewMonthlyRecords(days)
+	if len(monthly) != 12 {
+		t.Fatalf("expected 12 months, got %d", len(monthly))
+	}
+
+	if july := monthly[time.July-1]; july.LowestTemperature == nil || july.LowestTemperature.Value != 58 {
+		t.Errorf("expected a july low of 58, got %+v", july.LowestTemperature)
+	}
+
+	if monthly[time.July-1].WettestDay != nil {
+		t.Errorf("expected no wettest day in a dry july, got %+v", monthly[time.July-1].WettestDay)
+	}
+
+	if monthly[time.March-1].HighestTemperature != nil {
+		t.Errorf("expected no records in march, got %+v", monthly[time.March-1].HighestTemperature)
+	}
+}
+
+func TestValidDay(t *testing.T) {
+	tests := []struct {
+		month, day int
+		expected   bool
+	}{
+		{month: 2, day: 29, expected: true},
+		{month: 2, day: 30, expected: false},
+		{month: 4, day: 31, expected: false},
+		{month: 12, day: 31, expected: true},
+		{month: 13, day: 1, expected: false},
+		{month: 1, day: 0, expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := almanac.ValidDay(tt.month, tt.day); got != tt.expected {
+			t.Errorf("ValidDay(%d, %d): expected %t, got %t", tt.month, tt.day, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package catalog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+type Aggregation string
+
+const (
+	AggregationLast Aggregation = "last"
+	AggregationAvg  Aggregation = "avg"
+	AggregationMin  Aggregation = "min"
+	AggregationMax  Aggregation = "max"
+)
+
+// DefaultMaxAge is how old the latest reading of a metric may be before it is
+// stale, unless the metric sets its own MaxAge.
+const DefaultMaxAge = 10 * time.Minute
+
+// rangeAggregations are the aggregations returned by every ranged query.
+var rangeAggregations = []Aggregation{AggregationAvg, AggregationMin, AggregationMax}
+
+// Metric describes a single sensor column exposed by the api. Routes for the
+// latest value and the ranged windows are generated from it.
+type Metric struct {
+	Name   string
+	Table  string
+	Column string
+	// LastColumn overrides Column for the latest value, e.g. wind_speed reports
+	// the 10 minute high as its latest value but the instantaneous speed in ranges.
+	LastColumn string
+	// PerDevice marks airgradient metrics, which are selected with ?device=.
+	PerDevice bool
+	// Derived marks metrics computed from several columns of Table rather than
+	// read from Column, see the derived package.
+	Derived bool
+	// Circular marks directions in degrees, which are vector averaged in ranges
+	// since min, max and a plain average are meaningless for them.
+	Circular bool
+	// Quantity is set for metrics converted with ?units=, Unit is then the
+	// imperial unit the station reports in.
+	Quantity     units.Quantity
+	Unit         string
+	Description  string
+	Aggregations []Aggregation
+	// MaxAge overrides DefaultMaxAge for metrics reported less often.
+	MaxAge time.Duration
+}
+
+// Window is one of the fixed lookback windows served for every ranged metric.
+type Window struct {
+	Name             string
+	LookbackInterval string
+	TimeBucket       string
+}
+
+var Windows = []Window{
+	{Name: "12h", LookbackInterval: "12h", TimeBucket: "30m"},
+	{Name: "24h", LookbackInterval: "24h", TimeBucket: "1h"},
+	{Name: "7d", LookbackInterval: "7d", TimeBucket: "6h"},
+	{Name: "30d", LookbackInterval: "30d", TimeBucket: "1d"},
+}
+
+var Metrics = []Metric{
+	{
+		Name:         "temperature",
+		Table:        "vantagepro2plus",
+		Column:       "temperature",
+		Quantity:     units.Temperature,
+		Unit:         "°F",
+		Description:  "outdoor air temperature",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "humidity",
+		Table:        "vantagepro2plus",
+		Column:       "humidity",
+		Unit:         "%",
+		Description:  "outdoor relative humidity",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "pressure",
+		Table:        "vantagepro2plus",
+		Column:       "barometer_sea_level",
+		Quantity:     units.Pressure,
+		Unit:         "inHg",
+		Description:  "barometric pressure reduced to sea level",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "solar_radiation",
+		Table:        "vantagepro2plus",
+		Column:       "solar_radiation",
+		Unit:         "W/m²",
+		Description:  "global solar radiation",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "wind_speed",
+		Table:        "vantagepro2plus",
+		Column:       "wind_speed_last",
+		LastColumn:   "wind_speed_high_last_10_min",
+		Quantity:     units.Speed,
+		Unit:         "mph",
+		Description:  "wind speed; the latest value is the highest speed of the last 10 minutes",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "wind_direction",
+		Table:        "vantagepro2plus",
+		Column:       "wind_direction_last",
+		Circular:     true,
+		Unit:         "°",
+		Description:  "direction the wind blows from; ranges are vector averaged and weighted by speed",
+		Aggregations: []Aggregation{AggregationLast, AggregationAvg},
+	},
+	{
+		Name:         "24h_rain",
+		Table:        "vantagepro2plus",
+		Column:       "rain_last_24_hour",
+		Quantity:     units.Precipitation,
+		Unit:         "in",
+		Description:  "rainfall over the last 24 hours",
+		Aggregations: []Aggregation{AggregationLast},
+	},
+	{
+		Name:         "rain_rate",
+		Table:        "vantagepro2plus",
+		Column:       "rain_rate_last",
+		Quantity:     units.PrecipitationRate,
+		Unit:         "in/h",
+		Description:  "rainfall rate",
+		Aggregations: rangeAggregations,
+	},
+	{
+		Name:         "uv_index",
+		Table:        "vantagepro2plus",
+		Column:       "uv_index",
+		Unit:         "index",
+		Description:  "ultraviolet index",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "aqi",
+		Table:        "airgradient_aqi",
+		Column:       "aqi",
+		PerDevice:    true,
+		Unit:         "aqi",
+		Description:  "us epa air quality index",
+		Aggregations: withLast(rangeAggregations),
+		// aqi is derived from hourly averages and lags the raw readings
+		MaxAge: 90 * time.Minute,
+	},
+	{
+		Name:         "co2",
+		Table:        "airgradient",
+		Column:       "rco2",
+		PerDevice:    true,
+		Unit:         "ppm",
+		Description:  "carbon dioxide concentration",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "nox_index",
+		Table:        "airgradient",
+		Column:       "nox_index",
+		PerDevice:    true,
+		Unit:         "index",
+		Description:  "sensirion nitrogen oxides index",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "tvoc_index",
+		Table:        "airgradient",
+		Column:       "tvoc_index",
+		PerDevice:    true,
+		Unit:         "index",
+		Description:  "sensirion total volatile organic compounds index",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "dew_point",
+		Table:        "vantagepro2plus",
+		Derived:      true,
+		Quantity:     units.Temperature,
+		Unit:         "°F",
+		Description:  "dew point derived from temperature and humidity",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "heat_index",
+		Table:        "vantagepro2plus",
+		Derived:      true,
+		Quantity:     units.Temperature,
+		Unit:         "°F",
+		Description:  "nws heat index derived from temperature and humidity",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "wind_chill",
+		Table:        "vantagepro2plus",
+		Derived:      true,
+		Quantity:     units.Temperature,
+		Unit:         "°F",
+		Description:  "nws wind chill derived from temperature and wind speed",
+		Aggregations: withLast(rangeAggregations),
+	},
+	{
+		Name:         "feels_like",
+		Table:        "vantagepro2plus",
+		Derived:      true,
+		Quantity:     units.Temperature,
+		Unit:         "°F",
+		Description:  "apparent temperature: wind chill when cold, heat index when hot",
+		Aggregations: withLast(rangeAggregations),
+	},
+}
+
+func withLast(aggregations []Aggregation) []Aggregation {
+	return append([]Aggregation{AggregationLast}, aggregations...)
+}
+
+// Lookup returns the metric with the given name.
+func Lookup(name string) (Metric, bool) {
+	for _, m := range Metrics {
+		if m.Name == name {
+			return m, true
+		}
+	}
+
+	return Metric{}, false
+}
+
+func (m Metric) Supports(aggregation Aggregation) bool {
+	for _, a := range m.Aggregations {
+		if a == aggregation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Age returns how old a reading taken at t is at now, and whether that is
+// older than the metric's MaxAge.
+func (m Metric) Age(t time.Time, now time.Time) (time.Duration, bool) {
+	maxAge := m.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+
+	age := now.Sub(t)
+
+	return age, age > maxAge
+}
+
+// HasLast reports whether the metric serves a latest value.
+func (m Metric) HasLast() bool {
+	return m.Supports(AggregationLast)
+}
+
+// HasRange reports whether the metric serves bucketed ranges.
+func (m Metric) HasRange() bool {
+	return m.Supports(AggregationAvg) || m.Supports(AggregationMin) || m.Supports(AggregationMax)
+}
+
+// LatestColumn is the column read for the latest value.
+func (m Metric) LatestColumn() string {
+	if m.LastColumn != "" {
+		return m.LastColumn
+	}
+
+	return m.Column
+}
+
+// LastPath is the route of the latest value, relative to the api version prefix.
+func (m Metric) LastPath() string {
+	return fmt.Sprintf("/%s/last", m.Name)
+}
+
+// WindowPath is the route of a fixed window, relative to the api version prefix.
+func (m Metric) WindowPath(w Window) string {
+	return fmt.Sprintf("/%s/%s", m.Name, w.Name)
+}
+
+// RangePath is the route of arbitrary ranges, relative to the api version prefix.
+func (m Metric) RangePath() string {
+	return fmt.Sprintf("/%s", m.Name)
+}
+
+// UnitIn is the unit of the metric's values in the given unit system.
+func (m Metric) UnitIn(system units.System) string {
+	if m.Quantity.Convertible() {
+		return m.Quantity.Symbol(system)
+	}
+
+	return m.Unit
+}
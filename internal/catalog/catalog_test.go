@@ -0,0 +1,114 @@
+package catalog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/derived"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+func TestMetricsAreWellFormed(t *testing.T) {
+	seen := make(map[string]bool)
+
+	for _, metric := range catalog.Metrics {
+		if seen[metric.Name] {
+			t.Errorf("duplicate metric name %s", metric.Name)
+		}
+		seen[metric.Name] = true
+
+		if metric.Table == "" || (metric.Column == "" && !metric.Derived) {
+			t.Errorf("metric %s is missing a table or column", metric.Name)
+		}
+
+		if !metric.HasLast() && !metric.HasRange() {
+			t.Errorf("metric %s exposes no aggregations", metric.Name)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	metric, ok := catalog.Lookup("wind_speed")
+	if !ok {
+		t.Fatal("expected wind_speed to be in the catalog")
+	}
+
+	if metric.LatestColumn() != "wind_speed_high_last_10_min" {
+		t.Errorf("expected latest column wind_speed_high_last_10_min, got %s", metric.LatestColumn())
+	}
+
+	if metric.WindowPath(catalog.Windows[0]) != "/wind_speed/12h" {
+		t.Errorf("unexpected window path %s", metric.WindowPath(catalog.Windows[0]))
+	}
+
+	if _, ok := catalog.Lookup("metrics"); ok {
+		t.Error("expected metrics to not be a metric name")
+	}
+}
+
+func TestMetricsAreQueryable(t *testing.T) {
+	for _, metric := range catalog.Metrics {
+		if metric.Derived {
+			derivation, ok := derived.Lookup(metric.Name)
+			if !ok {
+				t.Errorf("metric %s has no derivation", metric.Name)
+				continue
+			}
+
+			for _, column := range derivation.Columns {
+				if err := timescale.ValidateColumn(derivation.Table, column); err != nil {
+					t.Errorf("metric %s: %v", metric.Name, err)
+				}
+			}
+			continue
+		}
+
+		if err := timescale.ValidateColumn(metric.Table, metric.Column); err != nil {
+			t.Errorf("metric %s: %v", metric.Name, err)
+		}
+
+		if err := timescale.ValidateColumn(metric.Table, metric.LatestColumn()); err != nil {
+			t.Errorf("metric %s: %v", metric.Name, err)
+		}
+	}
+}
+
+func TestConvertibleUnitsAreImperial(t *testing.T) {
+	for _, metric := range catalog.Metrics {
+		if metric.Quantity.Convertible() && metric.Unit != metric.UnitIn(units.Imperial) {
+			t.Errorf("metric %s: expected unit %s, got %s", metric.Name, metric.UnitIn(units.Imperial), metric.Unit)
+		}
+	}
+}
+
+func TestAge(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	temperature, _ := catalog.Lookup("temperature")
+	aqi, _ := catalog.Lookup("aqi")
+
+	tests := []struct {
+		name          string
+		metric        catalog.Metric
+		age           time.Duration
+		expectedStale bool
+	}{
+		{name: "Fresh", metric: temperature, age: time.Minute, expectedStale: false},
+		{name: "Default threshold", metric: temperature, age: catalog.DefaultMaxAge + time.Second, expectedStale: true},
+		{name: "Metric threshold", metric: aqi, age: time.Hour, expectedStale: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			age, stale := tt.metric.Age(now.Add(-tt.age), now)
+			if age != tt.age {
+				t.Errorf("expected age %s, got %s", tt.age, age)
+			}
+			if stale != tt.expectedStale {
+				t.Errorf("expected stale %t, got %t", tt.expectedStale, stale)
+			}
+		})
+	}
+}
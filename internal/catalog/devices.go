@@ -0,0 +1,85 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidDevice = errors.New("invalid device")
+	ErrNoDevices     = errors.New("no devices configured")
+)
+
+// Device is a single airgradient monitor. Readings from every monitor share the
+// airgradient tables and are told apart by serial number.
+type Device struct {
+	Name         string `json:"name"`
+	SerialNumber string `json:"serial_number"`
+	Location     string `json:"location,omitempty"`
+}
+
+// Devices is the device registry. The first device is the default.
+type Devices []Device
+
+// ParseDevices parses specs of the form name:serial_number[:location].
+func ParseDevices(specs []string) (Devices, error) {
+	var devices Devices
+
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		parts := strings.SplitN(spec, ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("%w: %q is not name:serial_number[:location]", ErrInvalidDevice, spec)
+		}
+
+		device := Device{
+			Name:         parts[0],
+			SerialNumber: parts[1],
+		}
+		if len(parts) == 3 {
+			device.Location = parts[2]
+		}
+
+		// names and serial numbers both select a device, so neither may repeat
+		if _, ok := devices.Lookup(device.Name); ok {
+			return nil, fmt.Errorf("%w: duplicate device %q", ErrInvalidDevice, device.Name)
+		}
+
+		if _, ok := devices.Lookup(device.SerialNumber); ok {
+			return nil, fmt.Errorf("%w: duplicate serial number %q", ErrInvalidDevice, device.SerialNumber)
+		}
+
+		devices = append(devices, device)
+	}
+
+	if len(devices) == 0 {
+		return nil, ErrNoDevices
+	}
+
+	return devices, nil
+}
+
+// Lookup finds a device by name or serial number.
+func (d Devices) Lookup(nameOrSerial string) (Device, bool) {
+	for _, device := range d {
+		if device.Name == nameOrSerial || device.SerialNumber == nameOrSerial {
+			return device, true
+		}
+	}
+
+	return Device{}, false
+}
+
+// Default is the device used when a request does not select one.
+func (d Devices) Default() Device {
+	if len(d) == 0 {
+		return Device{}
+	}
+
+	return d[0]
+}
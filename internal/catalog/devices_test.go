@@ -0,0 +1,78 @@
+package catalog_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+)
+
+func TestParseDevices(t *testing.T) {
+	tests := []struct {
+		name        string
+		specs       []string
+		expected    catalog.Devices
+		expectedErr error
+	}{
+		{
+			name:  "Indoor and outdoor",
+			specs: []string{"outdoor:84fce6070dd4:Back Yard", "indoor:abc123"},
+			expected: catalog.Devices{
+				{Name: "outdoor", SerialNumber: "84fce6070dd4", Location: "Back Yard"},
+				{Name: "indoor", SerialNumber: "abc123"},
+			},
+		},
+		{
+			name:        "Missing serial number",
+			specs:       []string{"outdoor"},
+			expectedErr: catalog.ErrInvalidDevice,
+		},
+		{
+			name:        "Duplicate name",
+			specs:       []string{"outdoor:a", "outdoor:b"},
+			expectedErr: catalog.ErrInvalidDevice,
+		},
+		{
+			name:        "Duplicate serial number",
+			specs:       []string{"a:123", "b:123"},
+			expectedErr: catalog.ErrInvalidDevice,
+		},
+		{
+			name:        "Empty",
+			specs:       []string{""},
+			expectedErr: catalog.ErrNoDevices,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devices, err := catalog.ParseDevices(tt.specs)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(devices) != len(tt.expected) {
+				t.Fatalf("expected %d devices, got %d", len(tt.expected), len(devices))
+			}
+			for i := range devices {
+				if devices[i] != tt.expected[i] {
+					t.Errorf("expected %+v, got %+v", tt.expected[i], devices[i])
+				}
+			}
+
+			if devices.Default() != tt.expected[0] {
+				t.Errorf("expected default %+v, got %+v", tt.expected[0], devices.Default())
+			}
+
+			if _, ok := devices.Lookup(tt.expected[0].SerialNumber); !ok {
+				t.Errorf("expected lookup by serial number to succeed")
+			}
+		})
+	}
+}
@@ -15,13 +15,34 @@ type Config struct {
 	DragonflyAuth        string        `env:"DRAGONFLY_AUTH"`
 	DragonflyKeyPrefix   string        `env:"DRAGONFLY_KEY_PREFIX" envDefault:"lfpweather"`
 	CacheResultsDuration time.Duration `env:"CACHE_RESULTS_DURATION" envDefault:"5m"`
+	RecordsCacheDuration time.Duration `env:"RECORDS_CACHE_DURATION" envDefault:"24h"`
 
 	TimescaleConnString string `env:"TIMESCALE_CONN_STRING,required"`
 	Port                int    `env:"PORT" envDefault:"8080"`
 
+	// the grpc api described by proto/lfpweather/v1 is served on its own port
+	GRPCPort int `env:"GRPC_PORT" envDefault:"9090"`
+
+	// answer coarse ranged queries from the continuous aggregates created by
+	// lfpweather-rollups, falling back to raw readings when they are missing
+	RollupsEnabled bool `env:"ROLLUPS_ENABLED" envDefault:"true"`
+
+	// live updates on /stream read the latest rows this often, and also as
+	// soon as the triggers created by lfpweather-rollups notify-create fire
+	// when listening is enabled
+	StreamPollInterval time.Duration `env:"STREAM_POLL_INTERVAL" envDefault:"10s"`
+	StreamListen       bool          `env:"STREAM_LISTEN" envDefault:"false"`
+
 	AuthenticationEnabled bool     `env:"AUTHENTICATION_ENABLED" envDefault:"false"`
 	APIKeys               []string `env:"API_KEYS"`
 
+	// local time zone of the station, used for calendar days and months and as
+	// the default ?tz= of ranged queries
+	StationTimezone string `env:"STATION_TIMEZONE" envDefault:"America/Los_Angeles"`
+
+	// airgradient monitors as name:serial_number[:location], the first is the default
+	AirGradientDevices []string `env:"AIRGRADIENT_DEVICES" envDefault:"outdoor:84fce6070dd4:Outdoor"`
+
 	// electricitymaps
 	ElectricityMapsAPIKey        string        `env:"ELECTRICITYMAPS_API_KEY,required"`
 	ElectricityMapsBaseURL       string        `env:"ELECTRICITYMAPS_BASE_URL"`
@@ -0,0 +1,172 @@
+package derived
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrMissingReading = errors.New("missing reading")
+
+// Derivation computes a metric from several columns of the same row, in Go
+// with Compute or in sql with SQL.
+type Derivation struct {
+	Name    string
+	Table   string
+	Columns []string
+	Compute func(values []float64) float64
+	SQL     func(columns []string) string
+}
+
+var Derivations = []Derivation{
+	{
+		Name:    "dew_point",
+		Table:   "vantagepro2plus",
+		Columns: []string{"temperature", "humidity"},
+		Compute: func(v []float64) float64 { return DewPoint(v[0], v[1]) },
+		SQL:     func(c []string) string { return DewPointSQL(c[0], c[1]) },
+	},
+	{
+		Name:    "heat_index",
+		Table:   "vantagepro2plus",
+		Columns: []string{"temperature", "humidity"},
+		Compute: func(v []float64) float64 { return HeatIndex(v[0], v[1]) },
+		SQL:     func(c []string) string { return HeatIndexSQL(c[0], c[1]) },
+	},
+	{
+		Name:    "wind_chill",
+		Table:   "vantagepro2plus",
+		Columns: []string{"temperature", "wind_speed_last"},
+		Compute: func(v []float64) float64 { return WindChill(v[0], v[1]) },
+		SQL:     func(c []string) string { return WindChillSQL(c[0], c[1]) },
+	},
+	{
+		Name:    "feels_like",
+		Table:   "vantagepro2plus",
+		Columns: []string{"temperature", "humidity", "wind_speed_last"},
+		Compute: func(v []float64) float64 { return FeelsLike(v[0], v[1], v[2]) },
+		SQL:     func(c []string) string { return FeelsLikeSQL(c[0], c[1], c[2]) },
+	},
+}
+
+// Lookup returns the derivation with the given name.
+func Lookup(name string) (Derivation, bool) {
+	for _, d := range Derivations {
+		if d.Name == name {
+			return d, true
+		}
+	}
+
+	return Derivation{}, false
+}
+
+// Value applies the derivation to a row, reporting false if any reading is missing.
+func (d Derivation) Value(row timescale.GetColumnsResponse) (float64, bool) {
+	values := make([]float64, len(row.Values))
+	for i, v := range row.Values {
+		if v == nil {
+			return 0, false
+		}
+		values[i] = *v
+	}
+
+	return d.Compute(values), true
+}
+
+// DerivedClient serves derived metrics in the same shapes as TimescaleClient
+// serves columns.
+type DerivedClient struct {
+	timescaleClient *timescale.TimescaleClient
+}
+
+func NewDerivedClient(timescaleClient *timescale.TimescaleClient) *DerivedClient {
+	return &DerivedClient{
+		timescaleClient: timescaleClient,
+	}
+}
+
+func cacheKey(prefix string, d Derivation, hash string) string {
+	return fmt.Sprintf("%s-derived-%s-%s", prefix, d.Name, hash)
+}
+
+// Expression is the derivation as a timescale expression of its columns.
+func (d Derivation) Expression() timescale.Expression {
+	return timescale.Expression{
+		Name:    d.Name,
+		Columns: d.Columns,
+		SQL:     d.SQL,
+	}
+}
+
+// GetColumn computes the derivation for every row in the range and buckets
+// the results in the database, like TimescaleClient.GetColumn.
+func (c *DerivedClient) GetColumn(ctx context.Context, d Derivation, tp timescale.GetColumnTemplateParameters) ([]timescale.GetColumnResponse, error) {
+	dfly := c.timescaleClient.Dfly
+	tp.TableName = d.Table
+
+	if dfly != nil {
+		res, err := dfly.GetClient().Get(ctx, cacheKey(dfly.KeyPrefix, d, tp.Hash())).Result()
+		if err == nil {
+			var getColumnResponses []timescale.GetColumnResponse
+			err := json.Unmarshal([]byte(res), &getColumnResponses)
+			if err != nil {
+				slog.Error("failed to unmarshal from dragonfly", slog.String("error", err.Error()))
+			}
+			return getColumnResponses, nil
+		} else if !errors.Is(err, redis.Nil) {
+			slog.Error("failed to get from dragonfly", slog.String("error", err.Error()))
+		}
+	}
+
+	var getColumnResponses []timescale.GetColumnResponse
+
+	err := c.timescaleClient.StreamExpression(ctx, d.Expression(), tp, func(row timescale.GetColumnResponse) error {
+		getColumnResponses = append(getColumnResponses, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if dfly != nil {
+		getColumnResponsesJSON, err := json.Marshal(getColumnResponses)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := dfly.GetClient().Set(ctx, cacheKey(dfly.KeyPrefix, d, tp.Hash()), getColumnResponsesJSON, dfly.CacheResultsDuration).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return getColumnResponses, nil
+}
+
+// GetColumnLast computes the derivation from the latest row.
+func (c *DerivedClient) GetColumnLast(ctx context.Context, d Derivation, tp timescale.GetColumnLastTemplateParameters) (*timescale.GetColumnLastResponse, error) {
+	row, err := c.timescaleClient.GetColumnsLast(ctx, timescale.GetColumnsLastTemplateParameters{
+		ColumnNames:  d.Columns,
+		TableName:    d.Table,
+		SerialNumber: tp.SerialNumber,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get readings for %s: %w", d.Name, err)
+	}
+
+	value, ok := d.Value(*row)
+	if !ok {
+		return nil, fmt.Errorf("%w: latest row at %s lacks an input of %s", ErrMissingReading, row.Time.Format(time.RFC3339), d.Name)
+	}
+
+	return &timescale.GetColumnLastResponse{
+		Time: row.Time,
+		Last: value,
+	}, nil
+}
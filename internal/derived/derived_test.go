@@ -0,0 +1,28 @@
+package derived
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDerivationSQL(t *testing.T) {
+	for _, d := range Derivations {
+		t.Run(d.Name, func(t *testing.T) {
+			if d.SQL == nil {
+				t.Fatal("expected a sql formula")
+			}
+
+			columns := make([]string, len(d.Columns))
+			for i, column := range d.Columns {
+				columns[i] = `"` + column + `"::float8`
+			}
+
+			sql := d.SQL(columns)
+			for _, column := range columns {
+				if !strings.Contains(sql, column) {
+					t.Errorf("expected %s in %s", column, sql)
+				}
+			}
+		})
+	}
+}
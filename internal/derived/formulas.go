@@ -0,0 +1,132 @@
+package derived
+
+import (
+	"fmt"
+	"math"
+)
+
+// All formulas take and return imperial units: °F, % relative humidity and mph.
+
+func fahrenheitToCelsius(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
+
+func celsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
+
+// DewPoint uses the Magnus approximation, accurate to within 0.35°C between
+// -45°C and 60°C.
+func DewPoint(temperature float64, humidity float64) float64 {
+	const (
+		a = 17.625
+		b = 243.04
+	)
+
+	// ln(0) is undefined, and a bone dry reading is a sensor fault anyway
+	humidity = math.Max(humidity, 1)
+
+	t := fahrenheitToCelsius(temperature)
+	gamma := math.Log(humidity/100) + a*t/(b+t)
+
+	return celsiusToFahrenheit(b * gamma / (a - gamma))
+}
+
+// HeatIndex follows the NWS algorithm: Steadman's simple formula below 80°F
+// and the Rothfusz regression with its low and high humidity adjustments above.
+func HeatIndex(temperature float64, humidity float64) float64 {
+	t, rh := temperature, humidity
+
+	simple := 0.5 * (t + 61 + (t-68)*1.2 + rh*0.094)
+	if (simple+t)/2 < 80 {
+		return (simple + t) / 2
+	}
+
+	hi := -42.379 +
+		2.04901523*t +
+		10.14333127*rh -
+		0.22475541*t*rh -
+		0.00683783*t*t -
+		0.05481717*rh*rh +
+		0.00122874*t*t*rh +
+		0.00085282*t*rh*rh -
+		0.00000199*t*t*rh*rh
+
+	switch {
+	case rh < 13 && t >= 80 && t <= 112:
+		hi -= ((13 - rh) / 4) * math.Sqrt((17-math.Abs(t-95))/17)
+	case rh > 85 && t >= 80 && t <= 87:
+		hi += ((rh - 85) / 10) * ((87 - t) / 5)
+	}
+
+	return hi
+}
+
+// WindChill follows the 2001 NWS formula, which is only defined at or below
+// 50°F with wind of at least 3 mph; outside that it is the air temperature.
+func WindChill(temperature float64, windSpeed float64) float64 {
+	if temperature > 50 || windSpeed < 3 {
+		return temperature
+	}
+
+	v := math.Pow(windSpeed, 0.16)
+
+	return 35.74 + 0.6215*temperature - 35.75*v + 0.4275*temperature*v
+}
+
+// FeelsLike is the NWS apparent temperature: wind chill when it is cold and
+// windy, heat index when it is hot, and the air temperature otherwise.
+func FeelsLike(temperature float64, humidity float64, windSpeed float64) float64 {
+	switch {
+	case temperature <= 50 && windSpeed >= 3:
+		return WindChill(temperature, windSpeed)
+	case temperature >= 80:
+		return HeatIndex(temperature, humidity)
+	default:
+		return temperature
+	}
+}
+
+// The sql formulas below compute the same values as the Go ones, from float8
+// sql expressions of the readings, so ranges can be bucketed in the database.
+
+func fahrenheitToCelsiusSQL(f string) string {
+	return fmt.Sprintf("((%s - 32) * 5 / 9)", f)
+}
+
+// DewPointSQL is DewPoint in sql.
+func DewPointSQL(temperature string, humidity string) string {
+	t := fahrenheitToCelsiusSQL(temperature)
+	gamma := fmt.Sprintf("(ln(GREATEST(%s, 1) / 100) + 17.625 * %s / (243.04 + %s))", humidity, t, t)
+
+	return fmt.Sprintf("(243.04 * %[1]s / (17.625 - %[1]s) * 9 / 5 + 32)", gamma)
+}
+
+// HeatIndexSQL is HeatIndex in sql.
+func HeatIndexSQL(temperature string, humidity string) string {
+	t, rh := temperature, humidity
+
+	simple := fmt.Sprintf("((0.5 * (%[1]s + 61 + (%[1]s - 68) * 1.2 + %[2]s * 0.094) + %[1]s) / 2)", t, rh)
+
+	hi := fmt.Sprintf("(-42.379 + 2.04901523 * %[1]s + 10.14333127 * %[2]s - 0.22475541 * %[1]s * %[2]s"+
+		" - 0.00683783 * %[1]s * %[1]s - 0.05481717 * %[2]s * %[2]s + 0.00122874 * %[1]s * %[1]s * %[2]s"+
+		" + 0.00085282 * %[1]s * %[2]s * %[2]s - 0.00000199 * %[1]s * %[1]s * %[2]s * %[2]s)", t, rh)
+
+	adjustment := fmt.Sprintf("(CASE WHEN %[2]s < 13 AND %[1]s >= 80 AND %[1]s <= 112 THEN -((13 - %[2]s) / 4) * sqrt((17 - abs(%[1]s - 95)) / 17)"+
+		" WHEN %[2]s > 85 AND %[1]s >= 80 AND %[1]s <= 87 THEN ((%[2]s - 85) / 10) * ((87 - %[1]s) / 5) ELSE 0 END)", t, rh)
+
+	return fmt.Sprintf("(CASE WHEN %[1]s < 80 THEN %[1]s ELSE %[2]s + %[3]s END)", simple, hi, adjustment)
+}
+
+// WindChillSQL is WindChill in sql.
+func WindChillSQL(temperature string, windSpeed string) string {
+	v := fmt.Sprintf("power(%s, 0.16)", windSpeed)
+
+	return fmt.Sprintf("(CASE WHEN %[1]s > 50 OR %[2]s < 3 THEN %[1]s ELSE 35.74 + 0.6215 * %[1]s - 35.75 * %[3]s + 0.4275 * %[1]s * %[3]s END)", temperature, windSpeed, v)
+}
+
+// FeelsLikeSQL is FeelsLike in sql.
+func FeelsLikeSQL(temperature string, humidity string, windSpeed string) string {
+	return fmt.Sprintf("(CASE WHEN %[1]s <= 50 AND %[3]s >= 3 THEN %[4]s WHEN %[1]s >= 80 THEN %[5]s ELSE %[1]s END)",
+		temperature, humidity, windSpeed, WindChillSQL(temperature, windSpeed), HeatIndexSQL(temperature, humidity))
+}
@@ -0,0 +1,37 @@
+package derived_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/derived"
+)
+
+func TestFormulas(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      float64
+		expected float64
+	}{
+		// reference values from the NWS calculators
+		{name: "Dew point 70F 50%", got: derived.DewPoint(70, 50), expected: 50.5},
+		{name: "Dew point saturated", got: derived.DewPoint(60, 100), expected: 60},
+		{name: "Heat index 90F 60%", got: derived.HeatIndex(90, 60), expected: 100},
+		{name: "Heat index 96F 80%", got: derived.HeatIndex(96, 80), expected: 138},
+		{name: "Heat index below 80F", got: derived.HeatIndex(70, 50), expected: 69.6},
+		{name: "Wind chill 30F 20mph", got: derived.WindChill(30, 20), expected: 17.4},
+		{name: "Wind chill calm", got: derived.WindChill(30, 2), expected: 30},
+		{name: "Wind chill warm", got: derived.WindChill(60, 20), expected: 60},
+		{name: "Feels like cold", got: derived.FeelsLike(30, 50, 20), expected: 17.4},
+		{name: "Feels like hot", got: derived.FeelsLike(90, 60, 5), expected: 100},
+		{name: "Feels like mild", got: derived.FeelsLike(65, 50, 10), expected: 65},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if math.Abs(tt.got-tt.expected) > 1 {
+				t.Errorf("expected %.1f, got %.1f", tt.expected, tt.got)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package downsample
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/redis/go-redis/v9"
+)
+
+// DownsampleClient serves chart ready series downsampled from raw readings.
+type DownsampleClient struct {
+	timescaleClient *timescale.TimescaleClient
+}
+
+func NewDownsampleClient(timescaleClient *timescale.TimescaleClient) *DownsampleClient {
+	return &DownsampleClient{
+		timescaleClient: timescaleClient,
+	}
+}
+
+func cacheKey(prefix string, name string, hash string, points int) string {
+	return fmt.Sprintf("%s-downsample-%s-%s-%d", prefix, name, hash, points)
+}
+
+// GetColumn reads the raw readings selected by tp, turns every row into a
+// value of the series name, skipping rows value rejects, and downsamples the
+// series to at most points points.
+func (c *DownsampleClient) GetColumn(ctx context.Context, name string, tp timescale.GetColumnsTemplateParameters, value func(timescale.GetColumnsResponse) (float64, bool), points int) ([]Point, error) {
+	dfly := c.timescaleClient.Dfly
+
+	if dfly != nil {
+		res, err := dfly.GetClient().Get(ctx, cacheKey(dfly.KeyPrefix, name, tp.Hash(), points)).Result()
+		if err == nil {
+			var downsampled []Point
+			err := json.Unmarshal([]byte(res), &downsampled)
+			if err != nil {
+				slog.Error("failed to unmarshal from dragonfly", slog.String("error", err.Error()))
+			}
+			return downsampled, nil
+		} else if !errors.Is(err, redis.Nil) {
+			slog.Error("failed to get from dragonfly", slog.String("error", err.Error()))
+		}
+	}
+
+	rows, err := c.timescaleClient.GetColumns(ctx, tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get readings for %s: %w", name, err)
+	}
+
+	series := make([]Point, 0, len(rows))
+	for _, row := range rows {
+		if v, ok := value(row); ok {
+			series = append(series, Point{Time: row.Time, Value: v})
+		}
+	}
+
+	downsampled := LTTB(series, points)
+
+	if dfly != nil {
+		downsampledJSON, err := json.Marshal(downsampled)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := dfly.GetClient().Set(ctx, cacheKey(dfly.KeyPrefix, name, tp.Hash(), points), downsampledJSON, dfly.CacheResultsDuration).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return downsampled, nil
+}
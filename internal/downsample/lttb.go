@@ -0,0 +1,71 @@
+package downsample
+
+import (
+	"math"
+	"time"
+)
+
+// Point is a single reading of a downsampled series.
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+	Unit  string    `json:"unit,omitempty"`
+}
+
+// LTTB downsamples time ordered points to at most threshold points with the
+// largest triangle three buckets algorithm, which keeps the points that shape
+// the series, such as peaks, rather than averaging them away. The first and
+// last points are always kept.
+func LTTB(points []Point, threshold int) []Point {
+	if threshold >= len(points) || threshold < 3 {
+		return points
+	}
+
+	sampled := make([]Point, 0, threshold)
+	sampled = append(sampled, points[0])
+
+	// the points between the first and last are split into threshold - 2
+	// buckets, and the point of each bucket forming the largest triangle with
+	// the previously kept point and the average of the next bucket is kept
+	every := float64(len(points)-2) / float64(threshold-2)
+	previous := 0
+
+	for i := 0; i < threshold-2; i++ {
+		start := int(math.Floor(float64(i)*every)) + 1
+		end := int(math.Floor(float64(i+1)*every)) + 1
+
+		nextStart := end
+		nextEnd := min(int(math.Floor(float64(i+2)*every))+1, len(points))
+
+		var avgX, avgY float64
+		for _, p := range points[nextStart:nextEnd] {
+			avgX += x(p)
+			avgY += p.Value
+		}
+		n := float64(nextEnd - nextStart)
+		avgX /= n
+		avgY /= n
+
+		ax, ay := x(points[previous]), points[previous].Value
+
+		largest := -1.0
+		selected := start
+		for j := start; j < end; j++ {
+			area := math.Abs((ax-avgX)*(points[j].Value-ay)-(ax-x(points[j]))*(avgY-ay)) / 2
+			if area > largest {
+				largest = area
+				selected = j
+			}
+		}
+
+		sampled = append(sampled, points[selected])
+		previous = selected
+	}
+
+	return append(sampled, points[len(points)-1])
+}
+
+// x is the horizontal coordinate of a point, in seconds.
+func x(p Point) float64 {
+	return float64(p.Time.UnixNano()) / float64(time.Second)
+}
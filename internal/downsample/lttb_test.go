@@ -0,0 +1,66 @@
+package downsample_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/downsample"
+)
+
+func series(n int, value func(i int) float64) []downsample.Point {
+	base := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	points := make([]downsample.Point, n)
+	for i := range points {
+		points[i] = downsample.Point{Time: base.Add(time.Duration(i) * time.Minute), Value: value(i)}
+	}
+
+	return points
+}
+
+func TestLTTB(t *testing.T) {
+	// a flat series with a single gust
+	points := series(1000, func(i int) float64 {
+		if i == 637 {
+			return 48
+		}
+		return 5 + math.Sin(float64(i)/50)
+	})
+
+	sampled := downsample.LTTB(points, 50)
+
+	if len(sampled) != 50 {
+		t.Fatalf("expected 50 points, got %d", len(sampled))
+	}
+
+	if !sampled[0].Time.Equal(points[0].Time) || !sampled[49].Time.Equal(points[999].Time) {
+		t.Error("expected the first and last points to be kept")
+	}
+
+	var peak bool
+	for i, p := range sampled {
+		if p.Value == 48 {
+			peak = true
+		}
+		if i > 0 && !p.Time.After(sampled[i-1].Time) {
+			t.Fatalf("expected points in time order, got %s after %s", p.Time, sampled[i-1].Time)
+		}
+	}
+
+	if !peak {
+		t.Error("expected the gust to be kept")
+	}
+}
+
+func TestLTTBShortSeries(t *testing.T) {
+	points := series(10, func(i int) float64 { return float64(i) })
+
+	if got := downsample.LTTB(points, 20); len(got) != 10 {
+		t.Errorf("expected all 10 points, got %d", len(got))
+	}
+
+	if got := downsample.LTTB(points, 2); len(got) != 10 {
+		t.Errorf("expected a threshold under 3 to keep all points, got %d", len(got))
+	}
+}
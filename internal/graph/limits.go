@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+var (
+	ErrTooDeep    = errors.New("query is too deep")
+	ErrTooComplex = errors.New("query is too complex")
+)
+
+// Limits bound a query before it runs. The depth of a query is how deeply its
+// fields nest, its complexity the sum of the cost of every field it selects,
+// aliases and fragments included. Introspection fields, whose names start with
+// __, are free so that tools can load the schema.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+	// Costs are the costs of fields by name, every other field costs 1.
+	Costs map[string]int
+}
+
+// Check measures the operation of doc that would run, returning ErrTooDeep or
+// ErrTooComplex when it exceeds the limits. A zero limit is not checked.
+func (l Limits) Check(doc *ast.Document, operationName string) error {
+	fragments := map[string]*ast.FragmentDefinition{}
+	var operations []*ast.OperationDefinition
+
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operations = append(operations, d)
+			}
+		}
+	}
+
+	// ambiguous or missing operations are reported when the query is executed
+	if len(operations) != 1 {
+		return nil
+	}
+
+	m := measure{limits: l, fragments: fragments, visiting: map[string]bool{}}
+	depth, complexity := m.selectionSet(operations[0].SelectionSet)
+
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("%w: depth %d exceeds %d", ErrTooDeep, depth, l.MaxDepth)
+	}
+
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return fmt.Errorf("%w: complexity %d exceeds %d", ErrTooComplex, complexity, l.MaxComplexity)
+	}
+
+	return nil
+}
+
+type measure struct {
+	limits    Limits
+	fragments map[string]*ast.FragmentDefinition
+	// visiting guards against fragment cycles, which fail validation later
+	visiting map[string]bool
+}
+
+// selectionSet returns the depth and complexity of a selection set.
+func (m *measure) selectionSet(set *ast.SelectionSet) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	var depth, complexity int
+
+	for _, selection := range set.Selections {
+		var d, c int
+
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+
+			d, c = m.selectionSet(s.SelectionSet)
+			d++
+
+			cost, ok := m.limits.Costs[s.Name.Value]
+			if !ok {
+				cost = 1
+			}
+			c += cost
+		case *ast.InlineFragment:
+			d, c = m.selectionSet(s.SelectionSet)
+		case *ast.FragmentSpread:
+			fragment, ok := m.fragments[s.Name.Value]
+			if !ok || m.visiting[s.Name.Value] {
+				continue
+			}
+
+			m.visiting[s.Name.Value] = true
+			d, c = m.selectionSet(fragment.SelectionSet)
+			m.visiting[s.Name.Value] = false
+		}
+
+		depth = max(depth, d)
+		complexity += c
+	}
+
+	return depth, complexity
+}
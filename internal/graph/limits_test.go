@@ -0,0 +1,48 @@
+package graph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/michaelpeterswa/lfpweather-api/internal/graph"
+)
+
+func TestLimitsCheck(t *testing.T) {
+	limits := graph.Limits{
+		MaxDepth:      3,
+		MaxComplexity: 30,
+		Costs:         map[string]int{"series": 10},
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		expected      error
+	}{
+		{name: "Within limits", query: `{ latest { value } series { time avg } }`},
+		{name: "Too deep", query: `{ a { b { c { d } } } }`, expected: graph.ErrTooDeep},
+		{name: "Aliases add up", query: `{ a: series { time } b: series { time } c: series { time } }`, expected: graph.ErrTooComplex},
+		{name: "Fragments are expanded", query: `{ ...deep } fragment deep on Query { a { b { c { d } } } }`, expected: graph.ErrTooDeep},
+		{name: "Inline fragments are expanded", query: `{ ... on Query { a { b { c { d } } } } }`, expected: graph.ErrTooDeep},
+		{name: "Fragment cycles end", query: `{ ...a } fragment a on Query { x ...b } fragment b on Query { y ...a }`},
+		{name: "Introspection is free", query: `{ __schema { types { fields { type { ofType { name } } } } } }`},
+		{name: "Selected operation", query: `query small { a } query large { a { b { c { d } } } }`, operationName: "small"},
+		{name: "Other operation", query: `query small { a } query large { a { b { c { d } } } }`, operationName: "large", expected: graph.ErrTooDeep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatalf("failed to parse query: %v", err)
+			}
+
+			err = limits.Check(doc, tt.operationName)
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
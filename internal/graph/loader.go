@@ -0,0 +1,134 @@
+// Package graph holds the pieces of the graphql api that do not depend on its
+// schema: batched loading of the values fields resolve to, and the limits that
+// bound the cost of a query before it runs.
+package graph
+
+import (
+	"context"
+	"sync"
+)
+
+// maxConcurrentLoads bounds how many keys of one loader are loaded at once.
+const maxConcurrentLoads = 8
+
+type dispatcher interface {
+	dispatch(ctx context.Context)
+}
+
+// Batch collects the keys requested from its loaders and loads all of them
+// together when the first value is needed. graphql-go resolves every field of
+// a level before it calls the thunks they return, so the loads of a level
+// form one batch. A Batch serves a single request.
+type Batch struct {
+	mu      sync.Mutex
+	loaders []dispatcher
+}
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+func (b *Batch) add(d dispatcher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.loaders = append(b.loaders, d)
+}
+
+// Dispatch loads the pending keys of every loader concurrently.
+func (b *Batch) Dispatch(ctx context.Context) {
+	b.mu.Lock()
+	loaders := b.loaders
+	b.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, l := range loaders {
+		wg.Add(1)
+		go func(l dispatcher) {
+			defer wg.Done()
+			l.dispatch(ctx)
+		}(l)
+	}
+	wg.Wait()
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Loader loads values by key once per request, however many fields ask for
+// them, deferring the loads to its Batch.
+type Loader[K comparable, V any] struct {
+	batch *Batch
+	load  func(ctx context.Context, key K) (V, error)
+
+	mu      sync.Mutex
+	results map[K]*result[V]
+	pending []K
+}
+
+func NewLoader[K comparable, V any](batch *Batch, load func(ctx context.Context, key K) (V, error)) *Loader[K, V] {
+	l := &Loader[K, V]{
+		batch:   batch,
+		load:    load,
+		results: map[K]*result[V]{},
+	}
+
+	batch.add(l)
+
+	return l
+}
+
+// Load requests the value of key and returns a thunk that waits for it,
+// dispatching the batch if the key has not been loaded yet.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	r, ok := l.results[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.results[key] = r
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		select {
+		case <-r.done:
+		default:
+			l.batch.Dispatch(ctx)
+			<-r.done
+		}
+
+		return r.value, r.err
+	}
+}
+
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, maxConcurrentLoads)
+
+	for _, key := range keys {
+		l.mu.Lock()
+		r := l.results[key]
+		l.mu.Unlock()
+
+		wg.Add(1)
+		limit <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-limit }()
+
+			r.value, r.err = l.load(ctx, key)
+			close(r.done)
+		}()
+	}
+
+	wg.Wait()
+}
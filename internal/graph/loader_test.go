@@ -0,0 +1,87 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/graph"
+)
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	batch := graph.NewBatch()
+
+	var (
+		mu     sync.Mutex
+		loaded []string
+	)
+
+	errMissing := errors.New("missing")
+
+	squares := graph.NewLoader(batch, func(_ context.Context, key int) (int, error) {
+		mu.Lock()
+		loaded = append(loaded, "square")
+		mu.Unlock()
+
+		return key * key, nil
+	})
+
+	names := graph.NewLoader(batch, func(_ context.Context, key string) (string, error) {
+		mu.Lock()
+		loaded = append(loaded, key)
+		mu.Unlock()
+
+		if key == "" {
+			return "", errMissing
+		}
+		return "hello " + key, nil
+	})
+
+	three := squares.Load(ctx, 3)
+	threeAgain := squares.Load(ctx, 3)
+	four := squares.Load(ctx, 4)
+	world := names.Load(ctx, "world")
+	missing := names.Load(ctx, "")
+
+	// the first value needed loads every pending key of every loader
+	v, err := three()
+	if err != nil || v != 9 {
+		t.Errorf("expected 9, got %d, %v", v, err)
+	}
+
+	mu.Lock()
+	slices.Sort(loaded)
+	if !slices.Equal(loaded, []string{"", "square", "square", "world"}) {
+		t.Errorf("expected one load per distinct key, got %v", loaded)
+	}
+	mu.Unlock()
+
+	v, err = threeAgain()
+	if err != nil || v != 9 {
+		t.Errorf("expected 9, got %d, %v", v, err)
+	}
+
+	v, err = four()
+	if err != nil || v != 16 {
+		t.Errorf("expected 16, got %d, %v", v, err)
+	}
+
+	s, err := world()
+	if err != nil || s != "hello world" {
+		t.Errorf("expected hello world, got %s, %v", s, err)
+	}
+
+	_, err = missing()
+	if !errors.Is(err, errMissing) {
+		t.Errorf("expected %v, got %v", errMissing, err)
+	}
+
+	// a key loaded before is not loaded again
+	_, _ = squares.Load(ctx, 4)()
+	if len(loaded) != 4 {
+		t.Errorf("expected a loaded key to be reused, got %d loads", len(loaded))
+	}
+}
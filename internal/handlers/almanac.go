@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/michaelpeterswa/lfpweather-api/internal/almanac"
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+// RecordsResponse is the all time and monthly records with their units.
+type RecordsResponse struct {
+	almanac.GetRecordsResponse
+	Units map[string]string `json:"units"`
+}
+
+// AlmanacDayResponse is a calendar day in every recorded year with its units.
+type AlmanacDayResponse struct {
+	almanac.GetDayResponse
+	Units map[string]string `json:"units"`
+}
+
+// almanacUnits are the units of records and daily extremes in system.
+func almanacUnits(system units.System) map[string]string {
+	return map[string]string{
+		"temperature": units.Temperature.Symbol(system),
+		"gust":        units.Speed.Symbol(system),
+		"rain":        units.Precipitation.Symbol(system),
+		"aqi":         "AQI",
+	}
+}
+
+func convertRecord(q units.Quantity, system units.System, r *almanac.Record) {
+	if r != nil {
+		r.Value = q.Convert(system, r.Value)
+	}
+}
+
+func convertRecords(system units.System, r *almanac.Records) {
+	convertRecord(units.Temperature, system, r.HighestTemperature)
+	convertRecord(units.Temperature, system, r.LowestTemperature)
+	convertRecord(units.Speed, system, r.MaxGust)
+	convertRecord(units.Precipitation, system, r.WettestDay)
+}
+
+func convertDailyExtremes(system units.System, d *timescale.DailyExtremes) {
+	convert(units.Temperature, system, d.HighTemperature)
+	convert(units.Temperature, system, d.LowTemperature)
+	convert(units.Speed, system, d.MaxGust)
+	convert(units.Precipitation, system, d.Rain)
+}
+
+// aqiSerialNumber resolves the ?device= whose air quality records are shown.
+func (s *ClimateHandler) aqiSerialNumber(w http.ResponseWriter, r *http.Request) (string, bool) {
+	metric, ok := catalog.Lookup("aqi")
+	if !ok {
+		return "", true
+	}
+
+	return serialNumber(w, r, s.devices, metric)
+}
+
+// GetRecords serves /records with all time and monthly records.
+func (s *ClimateHandler) GetRecords(w http.ResponseWriter, r *http.Request) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
+
+	serialNumber, ok := s.aqiSerialNumber(w, r)
+	if !ok {
+		return
+	}
+
+	records, err := s.almanacClient.GetRecords(r.Context(), s.location, serialNumber)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting records: %s", err.Error()))
+		return
+	}
+
+	convertRecords(system, &records.AllTime)
+	for i := range records.Monthly {
+		convertRecords(system, &records.Monthly[i].Records)
+	}
+
+	writeJSON(w, r, RecordsResponse{records, almanacUnits(system)}, "records")
+}
+
+// GetAlmanacDay serves /almanac/{month}-{day} with that calendar day in every
+// recorded year.
+func (s *ClimateHandler) GetAlmanacDay(w http.ResponseWriter, r *http.Request) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
+
+	serialNumber, ok := s.aqiSerialNumber(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	month, monthErr := strconv.Atoi(vars["month"])
+	day, dayErr := strconv.Atoi(vars["day"])
+	if monthErr != nil || dayErr != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid calendar day", fmt.Sprintf("%s-%s is not a month and day", vars["month"], vars["day"]))
+		return
+	}
+
+	almanacDay, err := s.almanacClient.GetDay(r.Context(), s.location, serialNumber, month, day)
+	if errors.Is(err, almanac.ErrInvalidDay) {
+		writeProblem(w, r, http.StatusBadRequest, "invalid calendar day", err.Error())
+		return
+	} else if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting almanac day: %s", err.Error()))
+		return
+	}
+
+	convertRecords(system, &almanacDay.Records)
+	for i := range almanacDay.Years {
+		convertDailyExtremes(system, &almanacDay.Years[i])
+	}
+	convert(units.Temperature, system, almanacDay.AverageHigh)
+	convert(units.Temperature, system, almanacDay.AverageLow)
+
+	writeJSON(w, r, AlmanacDayResponse{almanacDay, almanacUnits(system)}, "almanac day")
+}
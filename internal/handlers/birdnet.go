@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"alpineworks.io/rfc9457"
 	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
@@ -21,9 +22,25 @@ func NewBirdnetHandler(client *timescale.TimescaleClient) *BirdnetHandler {
 }
 
 func (bh *BirdnetHandler) GetBirdCount24h(w http.ResponseWriter, r *http.Request) {
-	birds, err := bh.timescaleClient.GetBirdnet(r.Context(), timescale.GetBirdnetTemplateParameters{
+	format, ok := responseFormat(w, r)
+	if !ok {
+		return
+	}
+
+	tp := timescale.GetBirdnetTemplateParameters{
 		LookbackInterval: "24h",
-	})
+	}
+
+	if format == formatCSV {
+		res := newCSVResponse(w, "birdnet-24h.csv", []string{"common_name", "count"})
+		err := bh.timescaleClient.StreamBirdnet(r.Context(), tp, func(bird timescale.GetBirdnetResponse) error {
+			return res.write([]string{bird.CommonName, strconv.Itoa(bird.Count)})
+		})
+		res.finish(r, err, "bird data")
+		return
+	}
+
+	birds, err := bh.timescaleClient.GetBirdnet(r.Context(), tp)
 
 	if err != nil {
 		statusCode := http.StatusInternalServerError
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+type CatalogHandler struct {
+	devices catalog.Devices
+}
+
+func NewCatalogHandler(devices catalog.Devices) *CatalogHandler {
+	return &CatalogHandler{
+		devices: devices,
+	}
+}
+
+type MetricDescription struct {
+	Name         string                  `json:"name"`
+	Unit         string                  `json:"unit"`
+	Units        map[units.System]string `json:"units"`
+	Description  string                  `json:"description"`
+	Aggregations []catalog.Aggregation   `json:"aggregations"`
+	PerDevice    bool                    `json:"per_device"`
+	Endpoints    map[string]string       `json:"endpoints"`
+}
+
+type DeviceDescription struct {
+	catalog.Device
+	Default bool `json:"default"`
+}
+
+// GetMetrics lists every catalog metric along with the routes that serve it.
+func (h *CatalogHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	// routes are relative to the version prefix this handler is mounted under
+	prefix := path.Dir(r.URL.Path)
+
+	descriptions := make([]MetricDescription, 0, len(catalog.Metrics))
+	for _, metric := range catalog.Metrics {
+		endpoints := make(map[string]string)
+		if metric.HasLast() {
+			endpoints["last"] = prefix + metric.LastPath()
+		}
+		if metric.HasRange() {
+			endpoints["range"] = prefix + metric.RangePath()
+			for _, window := range catalog.Windows {
+				endpoints[window.Name] = prefix + metric.WindowPath(window)
+			}
+		}
+
+		unitsBySystem := make(map[units.System]string, len(units.Systems))
+		for _, system := range units.Systems {
+			unitsBySystem[system] = metric.UnitIn(system)
+		}
+
+		descriptions = append(descriptions, MetricDescription{
+			Name:         metric.Name,
+			Unit:         metric.Unit,
+			Units:        unitsBySystem,
+			Description:  metric.Description,
+			Aggregations: metric.Aggregations,
+			PerDevice:    metric.PerDevice,
+			Endpoints:    endpoints,
+		})
+	}
+
+	writeJSON(w, r, descriptions, "metric catalog")
+}
+
+// GetDevices lists the airgradient monitors selectable with ?device=.
+func (h *CatalogHandler) GetDevices(w http.ResponseWriter, r *http.Request) {
+	descriptions := make([]DeviceDescription, 0, len(h.devices))
+	for i, device := range h.devices {
+		descriptions = append(descriptions, DeviceDescription{
+			Device:  device,
+			Default: i == 0,
+		})
+	}
+
+	writeJSON(w, r, descriptions, "device registry")
+}
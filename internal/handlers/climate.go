@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/almanac"
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+const (
+	defaultDailyLookback   = "30d"
+	defaultMonthlyLookback = "365d"
+)
+
+// ClimateHandler serves calendar based summaries, which are grouped by days
+// and months in the station's local time zone.
+type ClimateHandler struct {
+	timescaleClient *timescale.TimescaleClient
+	almanacClient   *almanac.AlmanacClient
+	devices         catalog.Devices
+	location        *time.Location
+}
+
+func NewClimateHandler(timescaleClient *timescale.TimescaleClient, almanacClient *almanac.AlmanacClient, devices catalog.Devices, location *time.Location) *ClimateHandler {
+	return &ClimateHandler{
+		timescaleClient: timescaleClient,
+		almanacClient:   almanacClient,
+		devices:         devices,
+		location:        location,
+	}
+}
+
+// calendarRange parses ?start=&end= for calendar summaries, defaulting start
+// to lookback before end.
+func (s *ClimateHandler) calendarRange(w http.ResponseWriter, r *http.Request, lookback string) (timerange.Range, bool) {
+	query := r.URL.Query()
+
+	start := query.Get("start")
+	if start == "" {
+		start = lookback
+	}
+
+	tr, err := timerange.Parse(start, query.Get("end"), time.Now())
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid time range", err.Error())
+		return timerange.Range{}, false
+	}
+
+	return tr, true
+}
+
+// dailyStart aligns the start of a range to local midnight, writing a problem
+// and returning false when the range spans too many days.
+func (s *ClimateHandler) dailyStart(w http.ResponseWriter, r *http.Request, tr timerange.Range) (time.Time, bool) {
+	start := timerange.StartOfDay(tr.Start, s.location)
+	if tr.End.Sub(start) > timerange.MaxBuckets*24*time.Hour {
+		writeProblem(w, r, http.StatusBadRequest, "invalid time range", fmt.Sprintf("daily summaries are limited to %d days", timerange.MaxBuckets))
+		return time.Time{}, false
+	}
+
+	return start, true
+}
+
+// GetClimateDaily serves /climate/daily?start=&end= with the highs, lows, max
+// gust, rain and averages of every local day the range touches, the last 30
+// days by default.
+func (s *ClimateHandler) GetClimateDaily(w http.ResponseWriter, r *http.Request) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
+
+	tr, ok := s.calendarRange(w, r, defaultDailyLookback)
+	if !ok {
+		return
+	}
+
+	start, ok := s.dailyStart(w, r, tr)
+	if !ok {
+		return
+	}
+
+	days, err := s.timescaleClient.GetClimateDaily(r.Context(), timescale.GetClimateDailyTemplateParameters{
+		Start:    start,
+		End:      tr.End,
+		Location: s.location,
+		Relative: tr.Relative,
+	})
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting daily climate: %s", err.Error()))
+		return
+	}
+
+	dayUnits := map[string]string{
+		"temperature": units.Temperature.Symbol(system),
+		"gust":        units.Speed.Symbol(system),
+		"rain":        units.Precipitation.Symbol(system),
+		"humidity":    "%",
+		"pressure":    units.Pressure.Symbol(system),
+	}
+
+	for i := range days {
+		convert(units.Temperature, system, days[i].HighTemperature)
+		convert(units.Temperature, system, days[i].LowTemperature)
+		convert(units.Speed, system, days[i].MaxGust)
+		convert(units.Pressure, system, days[i].AvgPressure)
+		days[i].Rain = units.Precipitation.Convert(system, days[i].Rain)
+		days[i].Units = dayUnits
+	}
+
+	writeJSON(w, r, days, "daily climate")
+}
+
+// convert converts an optional value of quantity q in place.
+func convert(q units.Quantity, system units.System, v *float64) {
+	if v != nil {
+		*v = q.Convert(system, *v)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type outputFormat string
+
+const (
+	formatJSON outputFormat = "json"
+	formatCSV  outputFormat = "csv"
+
+	csvContentType = "text/csv"
+	// csvFlushRows is how many rows are buffered before they are sent.
+	csvFlushRows = 500
+)
+
+// responseFormat resolves ?format=, falling back to the Accept header, which
+// selects csv when it prefers text/csv over application/json. It writes a
+// problem and returns false when the format is unknown.
+func responseFormat(w http.ResponseWriter, r *http.Request) (outputFormat, bool) {
+	switch f := outputFormat(strings.ToLower(r.URL.Query().Get("format"))); f {
+	case formatJSON, formatCSV:
+		return f, true
+	case "":
+	default:
+		writeProblem(w, r, http.StatusBadRequest, "invalid format", fmt.Sprintf("%s is not one of json or csv", f))
+		return "", false
+	}
+
+	if acceptQuality(r, csvContentType) > acceptQuality(r, "application/json") {
+		return formatCSV, true
+	}
+
+	return formatJSON, true
+}
+
+// acceptQuality returns the q value the Accept header gives an exact media
+// type, 0 when it is not listed.
+func acceptQuality(r *http.Request, mediaType string) float64 {
+	var quality float64
+
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			t, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || t != mediaType {
+				continue
+			}
+
+			q := 1.0
+			if value, ok := params["q"]; ok {
+				q, err = strconv.ParseFloat(value, 64)
+				if err != nil {
+					continue
+				}
+			}
+
+			quality = max(quality, q)
+		}
+	}
+
+	return quality
+}
+
+// csvResponse streams a csv attachment. Nothing is written before the first
+// record, so a failure before it can still be reported as a problem.
+type csvResponse struct {
+	w        http.ResponseWriter
+	csv      *csv.Writer
+	filename string
+	header   []string
+	started  bool
+	rows     int
+}
+
+func newCSVResponse(w http.ResponseWriter, filename string, header []string) *csvResponse {
+	return &csvResponse{
+		w:        w,
+		csv:      csv.NewWriter(w),
+		filename: filename,
+		header:   header,
+	}
+}
+
+// start writes the headers and the header row.
+func (c *csvResponse) start() error {
+	c.started = true
+
+	c.w.Header().Set("Content-Type", csvContentType+"; charset=utf-8")
+	c.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": c.filename}))
+
+	return c.csv.Write(c.header)
+}
+
+func (c *csvResponse) write(record []string) error {
+	if !c.started {
+		err := c.start()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := c.csv.Write(record)
+	if err != nil {
+		return err
+	}
+
+	c.rows++
+	if c.rows%csvFlushRows == 0 {
+		c.csv.Flush()
+		if flusher, ok := c.w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+
+	return c.csv.Error()
+}
+
+// finish writes the buffered rows, or a problem when err stopped the response
+// before any were written. Failures after that can only be logged.
+func (c *csvResponse) finish(r *http.Request, err error, subject string) {
+	if err != nil {
+		if !c.started {
+			writeProblem(c.w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting %s: %s", subject, err.Error()))
+			return
+		}
+
+		slog.Error("failed to stream csv", slog.String("subject", subject), slog.String("error", err.Error()))
+	}
+
+	// an empty result is still a csv with a header row
+	if !c.started {
+		err := c.start()
+		if err != nil {
+			slog.Error("failed to write csv", slog.String("error", err.Error()))
+		}
+	}
+
+	c.csv.Flush()
+
+	err = c.csv.Error()
+	if err != nil {
+		slog.Error("failed to write csv", slog.String("error", err.Error()))
+	}
+}
+
+// csvColumn names a csv column after a value and its unit.
+func csvColumn(name string, unit string) string {
+	if unit == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s (%s)", name, unit)
+}
+
+func csvTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+func csvFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// csvNullable renders a nullable value, empty when it is null.
+func csvNullable(v *float64) string {
+	if v == nil {
+		return ""
+	}
+
+	return csvFloat(*v)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/latest"
+)
+
+// CurrentReading is the latest value of a metric. Value is nil when the
+// latest row of its table has no reading of it.
+type CurrentReading struct {
+	Time       time.Time `json:"time"`
+	Value      *float64  `json:"value"`
+	Unit       string    `json:"unit,omitempty"`
+	Device     string    `json:"device,omitempty"`
+	AgeSeconds int64     `json:"age_seconds"`
+	Stale      bool      `json:"stale"`
+}
+
+// CurrentResponse holds the latest value of every metric with a latest value.
+type CurrentResponse struct {
+	Time    time.Time                 `json:"time"`
+	Metrics map[string]CurrentReading `json:"metrics"`
+}
+
+// GetCurrent serves /current with the latest value of every metric, reading
+// each table once and all tables concurrently. Metrics of tables without
+// readings are left out. With ?strict=true any stale metric fails the whole
+// document.
+func (s *WeatherHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
+
+	strict, ok := strictFreshness(w, r)
+	if !ok {
+		return
+	}
+
+	device := s.devices.Default()
+	if selector := r.URL.Query().Get("device"); selector != "" {
+		device, ok = s.devices.Lookup(selector)
+		if !ok {
+			writeProblem(w, r, http.StatusNotFound, "unknown device", fmt.Sprintf("%s is not a known device", selector))
+			return
+		}
+	}
+
+	groups, err := latest.Groups(device)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", err.Error())
+		return
+	}
+
+	latest.Fetch(r.Context(), s.timescaleClient, groups)
+
+	current := CurrentResponse{
+		Time:    time.Now(),
+		Metrics: map[string]CurrentReading{},
+	}
+
+	var (
+		staleMetrics []string
+		oldest       time.Duration
+	)
+
+	for _, g := range groups {
+		if g.Err != nil {
+			writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting current %s data: %s", g.Table, g.Err.Error()))
+			return
+		}
+
+		// the table has no readings yet, e.g. from a new or offline device
+		if g.Row == nil {
+			continue
+		}
+
+		for _, metric := range g.Metrics {
+			value := g.Value(metric)
+			if value != nil {
+				converted := metric.Quantity.Convert(system, *value)
+				value = &converted
+			}
+
+			age, stale := metric.Age(g.Row.Time, current.Time)
+			if stale {
+				staleMetrics = append(staleMetrics, metric.Name)
+				oldest = max(oldest, age)
+			}
+
+			current.Metrics[metric.Name] = CurrentReading{
+				Time:       g.Row.Time,
+				Value:      value,
+				Unit:       metric.UnitIn(system),
+				Device:     g.Device,
+				AgeSeconds: int64(age.Seconds()),
+				Stale:      stale,
+			}
+		}
+	}
+
+	if strict && len(staleMetrics) > 0 {
+		writeStaleProblem(w, r, staleMetrics, oldest)
+		return
+	}
+
+	writeJSON(w, r, current, "current conditions")
+}
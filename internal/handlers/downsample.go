@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/derived"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+const (
+	minDownsamplePoints = 3
+	// maxDownsampleRange bounds the raw readings read for a downsampled series.
+	maxDownsampleRange = 92 * 24 * time.Hour
+)
+
+// downsamplePoints parses ?points=, returning 0 when it is not set.
+func downsamplePoints(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("points")
+	if value == "" {
+		return 0, true
+	}
+
+	points, err := strconv.Atoi(value)
+	if err != nil || points < minDownsamplePoints || points > timerange.MaxBuckets {
+		writeProblem(w, r, http.StatusBadRequest, "invalid points", fmt.Sprintf("%s is not a number of points between %d and %d", value, minDownsamplePoints, timerange.MaxBuckets))
+		return 0, false
+	}
+
+	return points, true
+}
+
+// getColumnDownsampled serves a ranged query as at most points raw readings
+// chosen by LTTB, instead of time buckets.
+func (s *WeatherHandler) getColumnDownsampled(w http.ResponseWriter, r *http.Request, system units.System, metric catalog.Metric, tp timescale.GetColumnTemplateParameters, points int, format outputFormat) {
+	duration := tp.End.Sub(tp.Start)
+	if tp.Start.IsZero() {
+		d, err := timerange.ParseDuration(tp.LookbackInterval)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid time range", err.Error())
+			return
+		}
+		duration = d
+	}
+
+	if duration > maxDownsampleRange {
+		writeProblem(w, r, http.StatusBadRequest, "invalid time range", fmt.Sprintf("downsampled series are limited to %s of readings", timerange.FormatInterval(maxDownsampleRange)))
+		return
+	}
+
+	columns := []string{metric.Column}
+	value := func(row timescale.GetColumnsResponse) (float64, bool) {
+		if row.Values[0] == nil {
+			return 0, false
+		}
+		return *row.Values[0], true
+	}
+
+	if metric.Derived {
+		derivation, ok := derived.Lookup(metric.Name)
+		if !ok {
+			writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("no derivation for %s", metric.Name))
+			return
+		}
+
+		columns = derivation.Columns
+		value = derivation.Value
+	}
+
+	series, err := s.downsampleClient.GetColumn(r.Context(), metric.Name, timescale.GetColumnsTemplateParameters{
+		ColumnNames:      columns,
+		LookbackInterval: tp.LookbackInterval,
+		Start:            tp.Start,
+		End:              tp.End,
+		TableName:        metric.Table,
+		SerialNumber:     tp.SerialNumber,
+		Relative:         tp.Relative,
+	}, value, points)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting data for %s: %s", metric.Name, err.Error()))
+		return
+	}
+
+	unit := metric.UnitIn(system)
+	for i := range series {
+		series[i].Time = series[i].Time.In(tp.Location)
+		series[i].Value = metric.Quantity.Convert(system, series[i].Value)
+		series[i].Unit = unit
+	}
+
+	if format == formatCSV {
+		res := newCSVResponse(w, metric.Name+".csv", []string{"time", csvColumn(metric.Name, unit)})
+		for _, p := range series {
+			err = res.write([]string{csvTime(p.Time), csvFloat(p.Value)})
+			if err != nil {
+				break
+			}
+		}
+		res.finish(r, err, fmt.Sprintf("data for %s", metric.Name))
+		return
+	}
+
+	writeJSON(w, r, series, fmt.Sprintf("data for %s", metric.Name))
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+)
+
+const (
+	ndjsonContentType = "application/x-ndjson"
+	// exportFlushRows is how many rows are buffered before they are sent.
+	exportFlushRows = 1000
+)
+
+type ExportHandler struct {
+	timescaleClient *timescale.TimescaleClient
+}
+
+func NewExportHandler(timescaleClient *timescale.TimescaleClient) *ExportHandler {
+	return &ExportHandler{
+		timescaleClient: timescaleClient,
+	}
+}
+
+// GetExport serves /export/{table}?start=&end=&cursor= as newline delimited
+// json, one raw row per line in time order. An interrupted export is resumed
+// by passing the time of the last row received as ?cursor=; rows at exactly
+// that time are sent again and should be dropped as duplicates.
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	table := mux.Vars(r)["table"]
+	if !slices.Contains(timescale.ExportTables, table) {
+		writeProblem(w, r, http.StatusNotFound, "unknown table", fmt.Sprintf("%s is not one of the exported tables", table))
+		return
+	}
+
+	query := r.URL.Query()
+
+	tr, err := timerange.Parse(query.Get("start"), query.Get("end"), time.Now())
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid time range", err.Error())
+		return
+	}
+
+	var cursor time.Time
+	if value := query.Get("cursor"); value != "" {
+		cursor, err = time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid cursor", fmt.Sprintf("%s is not an RFC3339 timestamp", value))
+			return
+		}
+	}
+
+	var (
+		started bool
+		rows    int
+		buf     = bufio.NewWriter(w)
+	)
+
+	err = h.timescaleClient.Export(r.Context(), timescale.ExportTemplateParameters{
+		TableName: table,
+		Start:     tr.Start,
+		End:       tr.End,
+		Cursor:    cursor,
+	}, func(row json.RawMessage) error {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", ndjsonContentType)
+		}
+
+		_, err := buf.Write(row)
+		if err != nil {
+			return err
+		}
+
+		err = buf.WriteByte('\n')
+		if err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushRows == 0 {
+			err := buf.Flush()
+			if err != nil {
+				return err
+			}
+
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !started {
+			writeProblem(w, r, http.StatusInternalServerError, "failed to export data", fmt.Sprintf("error exporting %s: %s", table, err.Error()))
+			return
+		}
+
+		slog.Error("failed to stream export", slog.String("table", table), slog.String("error", err.Error()))
+	}
+
+	if !started {
+		w.Header().Set("Content-Type", ndjsonContentType)
+	}
+
+	err = buf.Flush()
+	if err != nil {
+		slog.Error("failed to write export", slog.String("error", err.Error()))
+	}
+}
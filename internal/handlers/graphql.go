@@ -0,0 +1,530 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/graph"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+	"github.com/michaelpeterswa/lfpweather-api/pkg/electricitymaps"
+)
+
+const (
+	// graphQLMaxBody bounds the size of a graphql request.
+	graphQLMaxBody = 64 << 10
+	// graphQLMaxBirds bounds the birds returned by a birds field.
+	graphQLMaxBirds = 100
+)
+
+// graphQLLimits bound every query. Fields that read the database or call
+// electricitymaps cost more than the fields of their results.
+var graphQLLimits = graph.Limits{
+	MaxDepth:      4,
+	MaxComplexity: 200,
+	Costs: map[string]int{
+		"latest": 5,
+		"series": 20,
+		"birds":  10,
+		"grid":   10,
+	},
+}
+
+// GraphQLRequest is a graphql query, sent as a json body or as query parameters.
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type latestKey struct {
+	metric       string
+	serialNumber string
+}
+
+// seriesKey is comparable so that identical series are loaded once, times are
+// kept as unix nanoseconds since time.Time compares its location too.
+type seriesKey struct {
+	metric       string
+	serialNumber string
+	bucket       string
+	start        int64
+	end          int64
+	location     string
+	relative     bool
+}
+
+// graphQLLoaders load the values of one request.
+type graphQLLoaders struct {
+	latest *graph.Loader[latestKey, *timescale.GetColumnLastResponse]
+	series *graph.Loader[seriesKey, []timescale.GetColumnResponse]
+	birds  *graph.Loader[string, []timescale.GetBirdnetResponse]
+	grid   *graph.Loader[string, *electricitymaps.GetPowerBreakdownLatestResponse]
+}
+
+type graphQLLoadersKey struct{}
+
+func loadersFrom(ctx context.Context) *graphQLLoaders {
+	return ctx.Value(graphQLLoadersKey{}).(*graphQLLoaders)
+}
+
+type GraphQLHandler struct {
+	weatherHandler        *WeatherHandler
+	electricityMapsClient *electricitymaps.ElectricityMapsClient
+	schema                graphql.Schema
+}
+
+func NewGraphQLHandler(weatherHandler *WeatherHandler, electricityMapsClient *electricitymaps.ElectricityMapsClient) (*GraphQLHandler, error) {
+	h := &GraphQLHandler{
+		weatherHandler:        weatherHandler,
+		electricityMapsClient: electricityMapsClient,
+	}
+
+	schema, err := h.newSchema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build graphql schema: %w", err)
+	}
+	h.schema = schema
+
+	return h, nil
+}
+
+func (h *GraphQLHandler) newLoaders() *graphQLLoaders {
+	batch := graph.NewBatch()
+
+	return &graphQLLoaders{
+		latest: graph.NewLoader(batch, func(ctx context.Context, key latestKey) (*timescale.GetColumnLastResponse, error) {
+			metric, _ := catalog.Lookup(key.metric)
+
+			return h.weatherHandler.getColumnLast(ctx, metric, timescale.GetColumnLastTemplateParameters{
+				ColumnName:   metric.LatestColumn(),
+				TableName:    metric.Table,
+				SerialNumber: key.serialNumber,
+			})
+		}),
+		series: graph.NewLoader(batch, func(ctx context.Context, key seriesKey) ([]timescale.GetColumnResponse, error) {
+			metric, _ := catalog.Lookup(key.metric)
+
+			location, err := time.LoadLocation(key.location)
+			if err != nil {
+				return nil, err
+			}
+
+			return h.weatherHandler.getColumn(ctx, metric, timescale.GetColumnTemplateParameters{
+				ColumnName:   metric.Column,
+				TimeBucket:   key.bucket,
+				Start:        time.Unix(0, key.start),
+				End:          time.Unix(0, key.end),
+				TableName:    metric.Table,
+				SerialNumber: key.serialNumber,
+				Location:     location,
+				Relative:     key.relative,
+			})
+		}),
+		birds: graph.NewLoader(batch, func(ctx context.Context, lookback string) ([]timescale.GetBirdnetResponse, error) {
+			return h.weatherHandler.timescaleClient.GetBirdnet(ctx, timescale.GetBirdnetTemplateParameters{
+				LookbackInterval: lookback,
+			})
+		}),
+		grid: graph.NewLoader(batch, func(ctx context.Context, zone string) (*electricitymaps.GetPowerBreakdownLatestResponse, error) {
+			return h.electricityMapsClient.GetPowerBreakdownLatest(ctx, zone)
+		}),
+	}
+}
+
+// thunk defers a loaded value, converting it with fn once it is loaded.
+// graphql-go recognizes exactly this function type as a deferred result.
+func thunk[V any](load func() (V, error), fn func(V) (any, error)) func() (any, error) {
+	return func() (any, error) {
+		v, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		return fn(v)
+	}
+}
+
+var unitSystemEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "UnitSystem",
+	Description: "unit system of converted values, imperial by default",
+	Values: graphql.EnumValueConfigMap{
+		"IMPERIAL": {Value: units.Imperial},
+		"METRIC":   {Value: units.Metric},
+		"SI":       {Value: units.SI},
+	},
+})
+
+var latestType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Latest",
+	Description: "latest value of a metric",
+	Fields: graphql.Fields{
+		"metric":     {Type: graphql.NewNonNull(graphql.String)},
+		"device":     {Type: graphql.String},
+		"time":       {Type: graphql.NewNonNull(graphql.DateTime)},
+		"value":      {Type: graphql.NewNonNull(graphql.Float)},
+		"unit":       {Type: graphql.String},
+		"ageSeconds": {Type: graphql.NewNonNull(graphql.Int)},
+		"stale":      {Type: graphql.NewNonNull(graphql.Boolean)},
+	},
+})
+
+var bucketType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Bucket",
+	Description: "one bucket of a series, aggregates are null for buckets without readings",
+	Fields: graphql.Fields{
+		"time": {Type: graphql.NewNonNull(graphql.DateTime)},
+		"avg":  {Type: graphql.Float},
+		"min":  {Type: graphql.Float},
+		"max":  {Type: graphql.Float},
+		"unit": {Type: graphql.String},
+	},
+})
+
+var birdCountType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "BirdCount",
+	Description: "detections of a species",
+	Fields: graphql.Fields{
+		"commonName": {Type: graphql.NewNonNull(graphql.String)},
+		"count":      {Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var powerBreakdownType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "PowerBreakdown",
+	Description: "latest power breakdown of an electricitymaps zone",
+	Fields: graphql.Fields{
+		"zone":                  {Type: graphql.NewNonNull(graphql.String)},
+		"datetime":              {Type: graphql.NewNonNull(graphql.DateTime)},
+		"renewablePercentage":   {Type: graphql.Int},
+		"fossilFreePercentage":  {Type: graphql.Int},
+		"powerConsumptionTotal": {Type: graphql.Int},
+		"powerProductionTotal":  {Type: graphql.Int},
+		"powerImportTotal":      {Type: graphql.Int},
+		"powerExportTotal":      {Type: graphql.Int},
+		"isEstimated":           {Type: graphql.Boolean},
+	},
+})
+
+func (h *GraphQLHandler) newSchema() (graphql.Schema, error) {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"latest": {
+				Type:        latestType,
+				Description: "latest value of a metric, of the default device unless device selects one",
+				Args: graphql.FieldConfigArgument{
+					"metric": {Type: graphql.NewNonNull(graphql.String)},
+					"device": {Type: graphql.String},
+					"units":  {Type: unitSystemEnum, DefaultValue: units.Imperial},
+				},
+				Resolve: h.resolveLatest,
+			},
+			"series": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(bucketType))),
+				Description: "bucketed range of a metric, start and end are RFC3339 timestamps or relative durations and the bucket is chosen automatically when omitted",
+				Args: graphql.FieldConfigArgument{
+					"metric": {Type: graphql.NewNonNull(graphql.String)},
+					"start":  {Type: graphql.String},
+					"end":    {Type: graphql.String},
+					"bucket": {Type: graphql.String},
+					"device": {Type: graphql.String},
+					"units":  {Type: unitSystemEnum, DefaultValue: units.Imperial},
+					"tz":     {Type: graphql.String, Description: "iana time zone buckets are aligned to, the station's by default"},
+				},
+				Resolve: h.resolveSeries,
+			},
+			"birds": {
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(birdCountType))),
+				Description: "species detected over the lookback, most detected first",
+				Args: graphql.FieldConfigArgument{
+					"lookback": {Type: graphql.String, DefaultValue: "24h", Description: "lookback of at most 366d"},
+					"limit":    {Type: graphql.Int, DefaultValue: graphQLMaxBirds},
+				},
+				Resolve: h.resolveBirds,
+			},
+			"grid": {
+				Type:        powerBreakdownType,
+				Description: "latest power breakdown of the electricity grid",
+				Args: graphql.FieldConfigArgument{
+					"zone": {Type: graphql.String, DefaultValue: electricitymaps.DefaultZone},
+				},
+				Resolve: h.resolveGrid,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// graphQLMetric looks up the metric and device a field selects.
+func (h *GraphQLHandler) graphQLMetric(args map[string]any) (catalog.Metric, string, error) {
+	name, _ := args["metric"].(string)
+
+	metric, ok := catalog.Lookup(name)
+	if !ok {
+		return catalog.Metric{}, "", fmt.Errorf("%s is not a known metric", name)
+	}
+
+	selector, _ := args["device"].(string)
+
+	serialNumber, problem := lookupSerialNumber(h.weatherHandler.devices, metric, selector)
+	if problem != nil {
+		return catalog.Metric{}, "", problem
+	}
+
+	return metric, serialNumber, nil
+}
+
+func (h *GraphQLHandler) resolveLatest(p graphql.ResolveParams) (any, error) {
+	metric, serialNumber, err := h.graphQLMetric(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	if !metric.HasLast() {
+		return nil, fmt.Errorf("%s has no latest value", metric.Name)
+	}
+
+	system, _ := p.Args["units"].(units.System)
+
+	// device is null for metrics of the station
+	var device any
+	if metric.PerDevice {
+		d, _ := h.weatherHandler.devices.Lookup(serialNumber)
+		device = d.Name
+	}
+
+	load := loadersFrom(p.Context).latest.Load(p.Context, latestKey{metric: metric.Name, serialNumber: serialNumber})
+
+	return thunk(load, func(last *timescale.GetColumnLastResponse) (any, error) {
+		age, stale := metric.Age(last.Time, time.Now())
+
+		return map[string]any{
+			"metric":     metric.Name,
+			"device":     device,
+			"time":       last.Time,
+			"value":      metric.Quantity.Convert(system, last.Last),
+			"unit":       metric.UnitIn(system),
+			"ageSeconds": int(age.Seconds()),
+			"stale":      stale,
+		}, nil
+	}), nil
+}
+
+func (h *GraphQLHandler) resolveSeries(p graphql.ResolveParams) (any, error) {
+	metric, serialNumber, err := h.graphQLMetric(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	if !metric.HasRange() || metric.Circular {
+		return nil, fmt.Errorf("%s has no bucketed series", metric.Name)
+	}
+
+	system, _ := p.Args["units"].(units.System)
+
+	start, _ := p.Args["start"].(string)
+	end, _ := p.Args["end"].(string)
+
+	tr, err := timerange.Parse(start, end, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	bucketArg, _ := p.Args["bucket"].(string)
+
+	bucket, err := timerange.ParseBucket(bucketArg, tr)
+	if err != nil {
+		return nil, err
+	}
+
+	location := h.weatherHandler.location
+	if tz, _ := p.Args["tz"].(string); tz != "" {
+		location, err = loadLocation(tz)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	load := loadersFrom(p.Context).series.Load(p.Context, seriesKey{
+		metric:       metric.Name,
+		serialNumber: serialNumber,
+		bucket:       timerange.FormatInterval(bucket),
+		start:        tr.Start.UnixNano(),
+		end:          tr.End.UnixNano(),
+		location:     location.String(),
+		relative:     tr.Relative,
+	})
+
+	return thunk(load, func(values []timescale.GetColumnResponse) (any, error) {
+		unit := metric.UnitIn(system)
+
+		// loaded values are shared by the fields that load them, so each
+		// field converts a copy
+		buckets := make([]map[string]any, 0, len(values))
+		for _, v := range values {
+			b := timescale.GetColumnResponse{Avg: copyFloat(v.Avg), Min: copyFloat(v.Min), Max: copyFloat(v.Max)}
+			convertBucket(metric.Quantity, system, &b)
+
+			buckets = append(buckets, map[string]any{
+				"time": v.Time.In(location),
+				"avg":  b.Avg,
+				"min":  b.Min,
+				"max":  b.Max,
+				"unit": unit,
+			})
+		}
+
+		return buckets, nil
+	}), nil
+}
+
+func copyFloat(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+
+	c := *v
+	return &c
+}
+
+func (h *GraphQLHandler) resolveBirds(p graphql.ResolveParams) (any, error) {
+	lookbackArg, _ := p.Args["lookback"].(string)
+
+	d, err := timerange.ParseDuration(lookbackArg)
+	if err != nil || d < time.Second || d > timescale.MaxBirdnetLookback {
+		return nil, fmt.Errorf("lookback %s is not a duration between 1s and %s", lookbackArg, timerange.FormatInterval(timescale.MaxBirdnetLookback))
+	}
+
+	// equal lookbacks such as 24h and 1d are loaded once
+	lookback := timerange.FormatInterval(d.Truncate(time.Second))
+
+	limit, _ := p.Args["limit"].(int)
+	if limit < 0 || limit > graphQLMaxBirds {
+		return nil, fmt.Errorf("limit must be between 0 and %d", graphQLMaxBirds)
+	}
+
+	load := loadersFrom(p.Context).birds.Load(p.Context, lookback)
+
+	return thunk(load, func(birds []timescale.GetBirdnetResponse) (any, error) {
+		counts := make([]map[string]any, 0, min(limit, len(birds)))
+		for _, bird := range birds[:min(limit, len(birds))] {
+			counts = append(counts, map[string]any{
+				"commonName": bird.CommonName,
+				"count":      bird.Count,
+			})
+		}
+
+		return counts, nil
+	}), nil
+}
+
+func (h *GraphQLHandler) resolveGrid(p graphql.ResolveParams) (any, error) {
+	zone, _ := p.Args["zone"].(string)
+
+	load := loadersFrom(p.Context).grid.Load(p.Context, zone)
+
+	return thunk(load, func(breakdown *electricitymaps.GetPowerBreakdownLatestResponse) (any, error) {
+		return map[string]any{
+			"zone":                  breakdown.Zone,
+			"datetime":              breakdown.Datetime,
+			"renewablePercentage":   breakdown.RenewablePercentage,
+			"fossilFreePercentage":  breakdown.FossilFreePercentage,
+			"powerConsumptionTotal": breakdown.PowerConsumptionTotal,
+			"powerProductionTotal":  breakdown.PowerProductionTotal,
+			"powerImportTotal":      breakdown.PowerImportTotal,
+			"powerExportTotal":      breakdown.PowerExportTotal,
+			"isEstimated":           breakdown.IsEstimated,
+		}, nil
+	}), nil
+}
+
+// parseGraphQLRequest reads a query from a json body or, for GET, from the
+// query, operationName and variables parameters.
+func parseGraphQLRequest(r *http.Request) (GraphQLRequest, error) {
+	var req GraphQLRequest
+
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+
+		if variables := query.Get("variables"); variables != "" {
+			err := json.Unmarshal([]byte(variables), &req.Variables)
+			if err != nil {
+				return req, fmt.Errorf("variables are not a json object: %w", err)
+			}
+		}
+	} else {
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			return req, fmt.Errorf("body is not a graphql request: %w", err)
+		}
+	}
+
+	if req.Query == "" {
+		return req, fmt.Errorf("the request has no query")
+	}
+
+	return req, nil
+}
+
+// ServeGraphQL serves /graphql. Queries are checked against graphQLLimits
+// before they run, and the values their fields load are batched, so that e.g.
+// several latest fields read the database concurrently and identical fields
+// read it once.
+func (h *GraphQLHandler) ServeGraphQL(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, graphQLMaxBody)
+
+	req, err := parseGraphQLRequest(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid graphql request", err.Error())
+		return
+	}
+
+	result := h.execute(r.Context(), req)
+
+	writeJSON(w, r, result, "graphql result")
+}
+
+func (h *GraphQLHandler) execute(ctx context.Context, req GraphQLRequest) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	validation := graphql.ValidateDocument(&h.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	err = graphQLLimits.Check(doc, req.OperationName)
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(ctx, graphQLLoadersKey{}, h.newLoaders()),
+	})
+
+	for _, e := range result.Errors {
+		slog.Debug("graphql error", slog.String("error", e.Message))
+	}
+
+	return result
+}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/downsample"
+	"github.com/michaelpeterswa/lfpweather-api/internal/middleware"
+	"github.com/michaelpeterswa/lfpweather-api/internal/openapi"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+// OpenAPIHandler serves the OpenAPI document of the api and a page rendering it.
+type OpenAPIHandler struct {
+	document []byte
+}
+
+// NewOpenAPIHandler describes every route of router, which must already be
+// registered, under the version prefix the api is mounted at. Routes without
+// a description are an error.
+func NewOpenAPIHandler(router *mux.Router, prefix string, authentication bool) (*OpenAPIHandler, error) {
+	d := openapi.New(openapi.Info{
+		Title:       "lfpweather-api",
+		Version:     strings.TrimPrefix(prefix[strings.LastIndex(prefix, "/")+1:], "v"),
+		Description: "weather station, air quality, bird and electricity grid data. Errors are RFC 9457 problems.",
+	})
+
+	if authentication {
+		d.RequireAPIKey("X-API-Key")
+	}
+
+	operations := openAPIOperations(d)
+
+	err := d.AddRoutes(router, func(method string, path string) (*openapi.Operation, bool) {
+		op, ok := operations[method+" "+strings.TrimPrefix(path, prefix)]
+		return op, ok
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe routes: %w", err)
+	}
+
+	document, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal openapi document: %w", err)
+	}
+
+	return &OpenAPIHandler{document: document}, nil
+}
+
+// GetOpenAPI serves /openapi.json.
+func (h *OpenAPIHandler) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write(h.document)
+	if err != nil {
+		slog.Error("failed to write openapi document", slog.String("error", err.Error()))
+	}
+}
+
+// docsContentSecurityPolicy keeps the docs, which may hold an api key, to
+// their own scripts. Swagger UI sets inline styles and data: images.
+const docsContentSecurityPolicy = "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; object-src 'none'; base-uri 'none'; frame-ancestors 'none'"
+
+// GetDocs serves /docs, rendering /openapi.json.
+func (h *OpenAPIHandler) GetDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", docsContentSecurityPolicy)
+	_, err := w.Write(openapi.DocsPage)
+	if err != nil {
+		slog.Error("failed to write docs", slog.String("error", err.Error()))
+	}
+}
+
+// GetDocsAssets serves the scripts and styles of /docs below /docs/.
+func (h *OpenAPIHandler) GetDocsAssets() http.Handler {
+	return http.StripPrefix("/docs/", http.FileServerFS(openapi.DocsAssets))
+}
+
+func enum[T ~string](values ...T) []any {
+	e := make([]any, 0, len(values))
+	for _, v := range values {
+		e = append(e, string(v))
+	}
+
+	return e
+}
+
+// openAPIParameters adds the query parameters shared by many routes, see the
+// selectors in weather.go, csv.go and downsample.go.
+func openAPIParameters(d *openapi.Document) map[string]*openapi.Parameter {
+	query := func(name string, description string, schema *openapi.Schema) *openapi.Parameter {
+		return d.AddParameter(name, &openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema})
+	}
+
+	var aggregations []string
+	for _, a := range timescale.BucketAggregations {
+		aggregations = append(aggregations, string(a))
+	}
+
+	return map[string]*openapi.Parameter{
+		"units": query("units", "unit system of converted values",
+			&openapi.Schema{Type: "string", Enum: enum(units.Systems...), Default: string(units.Imperial)}),
+		"device": query("device", "airgradient monitor by name or serial number, the first configured by default",
+			&openapi.Schema{Type: "string"}),
+		"strict": query("strict", "respond 503 instead when a reading is stale",
+			&openapi.Schema{Type: "boolean", Default: false}),
+		"format": query("format", "response format, csv is also selected by preferring text/csv in Accept",
+			&openapi.Schema{Type: "string", Enum: enum(formatJSON, formatCSV)}),
+		"start": query("start", fmt.Sprintf("RFC3339 timestamp or relative duration such as 24h or 7d before now, at most %s before end", timerange.FormatInterval(timerange.MaxRange)),
+			&openapi.Schema{Type: "string"}),
+		"end": query("end", "RFC3339 timestamp or relative duration, now by default",
+			&openapi.Schema{Type: "string"}),
+		"bucket": query("bucket", "bucket width such as 5m, 1h or 1d, chosen from the range by default",
+			&openapi.Schema{Type: "string"}),
+		"agg": query("agg", fmt.Sprintf("comma separated aggregations besides avg, min and max: %s or a percentile pN", strings.Join(aggregations, ", ")),
+			&openapi.Schema{Type: "string"}),
+		"fill": query("fill", "how buckets without readings are filled",
+			&openapi.Schema{Type: "string", Enum: enum(timescale.FillNull, timescale.FillLOCF, timescale.FillLinear), Default: string(timescale.FillNull)}),
+		"points": query("points", "downsample to at most this many raw readings instead of buckets",
+			&openapi.Schema{Type: "integer"}),
+		"tz": query("tz", "iana time zone buckets are aligned to, the station's by default",
+			&openapi.Schema{Type: "string"}),
+	}
+}
+
+// openAPIOperations describes every route by method and path below the
+// version prefix.
+func openAPIOperations(d *openapi.Document) map[string]*openapi.Operation {
+	p := openAPIParameters(d)
+
+	params := func(names ...string) []*openapi.Parameter {
+		parameters := make([]*openapi.Parameter, 0, len(names))
+		for _, name := range names {
+			parameters = append(parameters, p[name])
+		}
+		return parameters
+	}
+
+	// withCSV adds the csv rendering of a json response
+	withCSV := func(content map[string]openapi.MediaType) map[string]openapi.MediaType {
+		content[csvContentType] = openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+		return content
+	}
+
+	stale := map[string]*openapi.Response{
+		fmt.Sprint(http.StatusServiceUnavailable): openapi.ProblemResponse(),
+	}
+
+	responses := func(ok map[string]*openapi.Response, more ...map[string]*openapi.Response) map[string]*openapi.Response {
+		for _, m := range more {
+			for status, r := range m {
+				ok[status] = r
+			}
+		}
+		return ok
+	}
+
+	buckets := d.Schema([]timescale.GetColumnResponse{})
+	points := d.Schema([]downsample.Point{})
+	directions := d.Schema([]timescale.GetWindDirectionResponse{})
+
+	rain := func(summary string) *openapi.Operation {
+		return &openapi.Operation{
+			Tags:       []string{"climate"},
+			Summary:    summary,
+			Parameters: params("units", "start", "end"),
+			Responses:  openapi.OK("rain totals by period", d.JSON([]timescale.GetRainAccumulationResponse{})),
+		}
+	}
+
+	graphQLResponse := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"data":   {Type: []string{"object", "null"}},
+			"errors": {Type: "array", Items: &openapi.Schema{Type: "object"}},
+		},
+	}
+
+	var exportTables []any
+	for _, table := range timescale.ExportTables {
+		exportTables = append(exportTables, table)
+	}
+
+	var rangeMetrics []any
+
+	operations := map[string]*openapi.Operation{
+		"GET /metrics": {
+			Tags:      []string{"catalog"},
+			Summary:   "every metric and the routes that serve it",
+			Responses: openapi.OK("the metric catalog", d.JSON([]MetricDescription{})),
+		},
+		"GET /devices": {
+			Tags:      []string{"catalog"},
+			Summary:   "the airgradient monitors selectable with device",
+			Responses: openapi.OK("the device registry", d.JSON([]DeviceDescription{})),
+		},
+		"GET /electricitymaps/power_breakdown/latest": {
+			Tags:      []string{"grid"},
+			Summary:   "latest power breakdown of the electricity grid",
+			Responses: openapi.OK("the power breakdown", d.JSON(GetPowerBreakdownLatestResponse{})),
+		},
+		"GET /birdnet/24h": {
+			Tags:       []string{"birds"},
+			Summary:    "species detected over the last 24 hours, most detected first",
+			Parameters: params("format"),
+			Responses:  openapi.OK("detections by species", withCSV(d.JSON([]timescale.GetBirdnetResponse{}))),
+		},
+		"GET /current": {
+			Tags:       []string{"weather"},
+			Summary:    "latest value of every metric",
+			Parameters: params("units", "strict", "device"),
+			Responses:  responses(openapi.OK("current conditions", d.JSON(CurrentResponse{})), stale),
+		},
+		"POST /query": {
+			Tags:        []string{"weather"},
+			Summary:     "several series in one request",
+			Description: "queries that fail carry a problem instead of data",
+			Parameters:  params("units", "tz"),
+			RequestBody: &openapi.RequestBody{Required: true, Content: d.JSON(BatchRequest{})},
+			Responses:   openapi.OK("the series in the order of the queries", d.JSON(BatchResponse{})),
+		},
+		"GET /wind/rose": {
+			Tags:    []string{"weather"},
+			Summary: "readings counted by compass sector and speed",
+			Parameters: append(params("units"), &openapi.Parameter{
+				Name: "range", In: "query", Description: "lookback of at most 366d", Schema: &openapi.Schema{Type: "string", Default: "24h"},
+			}),
+			Responses: openapi.OK("the wind rose", d.JSON(timescale.GetWindRoseResponse{})),
+		},
+		"GET /rain/daily":   rain("rain total of every local day of a range, the last 30 days by default"),
+		"GET /rain/monthly": rain("rain total of every local month of a range, the last year by default"),
+		"GET /rain/total":   rain("rain total of a range, the last 24 hours by default"),
+		"GET /rain/summary": {
+			Tags:       []string{"climate"},
+			Summary:    "rain today, this month, this year and this water year",
+			Parameters: params("units"),
+			Responses:  openapi.OK("rain totals", d.JSON(RainSummaryResponse{})),
+		},
+		"GET /climate/daily": {
+			Tags:       []string{"climate"},
+			Summary:    "highs, lows, max gust, rain and averages of every local day of a range, the last 30 days by default",
+			Parameters: params("units", "start", "end"),
+			Responses:  openapi.OK("daily summaries", d.JSON([]timescale.GetClimateDailyResponse{})),
+		},
+		"GET /records": {
+			Tags:       []string{"climate"},
+			Summary:    "all time and monthly records",
+			Parameters: params("units", "device"),
+			Responses:  openapi.OK("the records", d.JSON(RecordsResponse{})),
+		},
+		"GET /almanac/{month}-{day}": {
+			Tags:       []string{"climate"},
+			Summary:    "a calendar day in every recorded year",
+			Parameters: params("units", "device"),
+			Responses:  openapi.OK("the calendar day", d.JSON(AlmanacDayResponse{})),
+		},
+		"GET /graphql": {
+			Tags:    []string{"graphql"},
+			Summary: "run a graphql query",
+			Parameters: []*openapi.Parameter{
+				{Name: "query", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+				{Name: "operationName", In: "query", Schema: &openapi.Schema{Type: "string"}},
+				{Name: "variables", In: "query", Description: "json object", Schema: &openapi.Schema{Type: "string"}},
+			},
+			Responses: openapi.OK("the graphql result", map[string]openapi.MediaType{"application/json": {Schema: graphQLResponse}}),
+		},
+		"POST /graphql": {
+			Tags:        []string{"graphql"},
+			Summary:     "run a graphql query",
+			RequestBody: &openapi.RequestBody{Required: true, Content: d.JSON(GraphQLRequest{})},
+			Responses:   openapi.OK("the graphql result", map[string]openapi.MediaType{"application/json": {Schema: graphQLResponse}}),
+		},
+		"GET /stream": {
+			Tags:    []string{"live"},
+			Summary: "new readings as server-sent events",
+			Description: "reading events carry a StreamReading as data. Clients start with the latest value of every selected metric, " +
+				"or with the events they missed when they reconnect with Last-Event-ID.",
+			Parameters: append(params("units", "device"),
+				&openapi.Parameter{Name: "metrics", In: "query", Description: "comma separated metrics, every metric by default", Schema: &openapi.Schema{Type: "string"}},
+				&openapi.Parameter{Name: "Last-Event-ID", In: "header", Description: "id of the last event received", Schema: &openapi.Schema{Type: "string"}},
+			),
+			Responses: openapi.OK("the event stream", map[string]openapi.MediaType{
+				"text/event-stream": {Schema: &openapi.Schema{Type: "string"}},
+			}),
+		},
+		"GET /socket": {
+			Tags:    []string{"live"},
+			Summary: "subscribe to readings and rolling aggregates over a websocket",
+			Description: fmt.Sprintf("the %s subprotocol exchanges json messages: clients send SocketRequests and receive "+
+				"SocketSubscription, SocketReading, SocketAggregate and SocketError messages. "+
+				"Browsers may send the api key as the subprotocol %s<key>.", SocketProtocol, middleware.APIKeyProtocolPrefix),
+			Parameters: params("units"),
+			Responses: map[string]*openapi.Response{
+				fmt.Sprint(http.StatusSwitchingProtocols): {Description: "switched to the websocket protocol"},
+			},
+		},
+		"GET /export/{table}": {
+			Tags:    []string{"export"},
+			Summary: "raw rows of a table as newline delimited json",
+			Parameters: append(params("start", "end"),
+				&openapi.Parameter{Name: "table", In: "path", Required: true, Schema: &openapi.Schema{Type: "string", Enum: exportTables}},
+				&openapi.Parameter{Name: "cursor", In: "query", Description: "time of the last row received, to resume an export", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+			),
+			Responses: openapi.OK("one row per line", map[string]openapi.MediaType{
+				ndjsonContentType: {Schema: &openapi.Schema{Type: "object"}},
+			}),
+		},
+	}
+
+	// weather metrics, generated from the catalog like their routes
+	for _, metric := range catalog.Metrics {
+		device := func(names ...string) []*openapi.Parameter {
+			if metric.PerDevice {
+				names = append(names, "device")
+			}
+			return params(names...)
+		}
+
+		if metric.HasLast() {
+			operations["GET "+metric.LastPath()] = &openapi.Operation{
+				Tags:       []string{"metrics"},
+				Summary:    "latest " + metric.Description,
+				Parameters: device("units", "strict", "format"),
+				Responses:  responses(openapi.OK("the latest reading", withCSV(d.JSON(timescale.GetColumnLastResponse{}))), stale),
+			}
+		}
+
+		if !metric.HasRange() {
+			continue
+		}
+
+		rangeMetrics = append(rangeMetrics, metric.Name)
+
+		// directions are vector averaged and cannot be aggregated further
+		series := &openapi.Schema{OneOf: []*openapi.Schema{buckets, points}}
+		names := []string{"units", "format", "tz", "agg", "fill", "points"}
+		if metric.Circular {
+			series = directions
+			names = []string{"units", "format", "tz"}
+		}
+
+		for _, window := range catalog.Windows {
+			operations["GET "+metric.WindowPath(window)] = &openapi.Operation{
+				Tags:       []string{"metrics"},
+				Summary:    fmt.Sprintf("%s over the last %s", metric.Description, window.Name),
+				Parameters: device(names...),
+				Responses:  openapi.OK("buckets, or raw readings with points", withCSV(map[string]openapi.MediaType{"application/json": {Schema: series}})),
+			}
+		}
+	}
+
+	operations["GET /{metric}"] = &openapi.Operation{
+		Tags:        []string{"metrics"},
+		Summary:     "a metric over an arbitrary range",
+		Description: "buckets without readings are null unless filled, directions are vector averaged instead",
+		Parameters: append(params("start", "end", "bucket", "units", "format", "tz", "agg", "fill", "points", "device"),
+			&openapi.Parameter{Name: "metric", In: "path", Required: true, Schema: &openapi.Schema{Type: "string", Enum: rangeMetrics}},
+		),
+		Responses: openapi.OK("buckets, or raw readings with points", withCSV(map[string]openapi.MediaType{
+			"application/json": {Schema: &openapi.Schema{OneOf: []*openapi.Schema{buckets, points, directions}}},
+		})),
+	}
+
+	return operations
+}
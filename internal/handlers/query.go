@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+const (
+	// maxBatchQueries bounds the number of series in one batch request.
+	maxBatchQueries = 32
+	// batchWorkers bounds how many series of a batch are queried at once.
+	batchWorkers = 4
+	// maxBatchBodyBytes bounds the size of a batch request body.
+	maxBatchBodyBytes = 64 << 10
+)
+
+// BatchQuery selects one series. Start and end are parsed like the ranged
+// metric endpoints, range is a shorthand for a start that long before end,
+// and the aggregation defaults to avg, or last for metrics without ranges.
+// Besides the catalog's aggregations any ?agg= aggregation such as p95 is
+// accepted, while last always selects the latest value. Fill fills empty
+// buckets like ?fill= does, and is rejected for vector averaged metrics.
+type BatchQuery struct {
+	ID          string              `json:"id,omitempty"`
+	Metric      string              `json:"metric"`
+	Start       string              `json:"start,omitempty"`
+	End         string              `json:"end,omitempty"`
+	Range       string              `json:"range,omitempty"`
+	Bucket      string              `json:"bucket,omitempty"`
+	Aggregation catalog.Aggregation `json:"aggregation,omitempty"`
+	Device      string              `json:"device,omitempty"`
+	Fill        string              `json:"fill,omitempty"`
+}
+
+type BatchRequest struct {
+	Queries []BatchQuery `json:"queries"`
+}
+
+// BatchPoint is one value of a series, Value is null for buckets without
+// readings.
+type BatchPoint struct {
+	Time  time.Time `json:"time"`
+	Value *float64  `json:"value"`
+}
+
+// BatchResult is the series of one query, or the problem that prevented it.
+type BatchResult struct {
+	ID          string              `json:"id,omitempty"`
+	Metric      string              `json:"metric"`
+	Aggregation catalog.Aggregation `json:"aggregation,omitempty"`
+	Unit        string              `json:"unit,omitempty"`
+	Data        []BatchPoint        `json:"data"`
+	Problem     json.RawMessage     `json:"problem,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// PostQuery serves POST /query, querying every series of the batch with a
+// bounded number of workers. Results are in the order of the queries, and a
+// query that fails carries a problem instead of data. ?units= and ?tz= apply
+// to every series.
+func (s *WeatherHandler) PostQuery(w http.ResponseWriter, r *http.Request) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
+
+	loc, ok := timezone(w, r, s.location)
+	if !ok {
+		return
+	}
+
+	var req BatchRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(&req)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid batch", fmt.Sprintf("error decoding batch: %s", err.Error()))
+		return
+	}
+
+	if len(req.Queries) == 0 || len(req.Queries) > maxBatchQueries {
+		writeProblem(w, r, http.StatusBadRequest, "invalid batch", fmt.Sprintf("a batch must have between 1 and %d queries", maxBatchQueries))
+		return
+	}
+
+	results := make([]BatchResult, len(req.Queries))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(batchWorkers, len(req.Queries)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.batchQuery(r, i, system, loc, req.Queries[i])
+			}
+		}()
+	}
+
+	for i := range req.Queries {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	writeJSON(w, r, BatchResponse{Results: results}, "batch")
+}
+
+// batchQuery runs the i-th query of a batch. It runs on a worker goroutine,
+// where net/http cannot recover a panic, so a panic is recovered here and
+// fails only this query.
+func (s *WeatherHandler) batchQuery(r *http.Request, i int, system units.System, loc *time.Location, q BatchQuery) (result BatchResult) {
+	result = BatchResult{
+		ID:     q.ID,
+		Metric: q.Metric,
+	}
+
+	instance := fmt.Sprintf("%s#/queries/%d", r.URL.Path, i)
+
+	defer func() {
+		if v := recover(); v != nil {
+			slog.Error("batch query panicked", slog.String("instance", instance), slog.Any("panic", v), slog.String("stack", string(debug.Stack())))
+
+			problem, jsonErr := problemJSON(instance, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("internal error querying %s", q.Metric))
+			if jsonErr != nil {
+				slog.Error("failed to marshal problem", slog.String("error", jsonErr.Error()))
+			}
+			result = BatchResult{
+				ID:      q.ID,
+				Metric:  q.Metric,
+				Problem: json.RawMessage(problem),
+			}
+		}
+	}()
+
+	data, aggregation, err := s.querySeries(r.Context(), system, loc, q)
+	if err != nil {
+		problem, jsonErr := problemJSON(instance, err.status, err.title, err.detail)
+		if jsonErr != nil {
+			slog.Error("failed to marshal problem", slog.String("error", jsonErr.Error()))
+		}
+		result.Problem = json.RawMessage(problem)
+		return result
+	}
+
+	metric, _ := catalog.Lookup(q.Metric)
+
+	result.Aggregation = aggregation
+	result.Unit = metric.UnitIn(system)
+	result.Data = data
+
+	return result
+}
+
+// querySeries reads one aggregation of a metric, in system units.
+func (s *WeatherHandler) querySeries(ctx context.Context, system units.System, loc *time.Location, q BatchQuery) ([]BatchPoint, catalog.Aggregation, *problemError) {
+	metric, ok := catalog.Lookup(q.Metric)
+	if !ok {
+		return nil, "", newProblemError(http.StatusNotFound, "unknown metric", fmt.Sprintf("%s is not a known metric", q.Metric))
+	}
+
+	aggregation := q.Aggregation
+	if aggregation == "" {
+		aggregation = catalog.AggregationAvg
+		if !metric.HasRange() {
+			aggregation = catalog.AggregationLast
+		}
+	}
+
+	// aggregations beyond the catalog's are bucket aggregations like p95
+	var bucketAggregation timescale.BucketAggregation
+	switch aggregation {
+	case catalog.AggregationLast, catalog.AggregationAvg, catalog.AggregationMin, catalog.AggregationMax:
+		if !metric.Supports(aggregation) {
+			return nil, "", newProblemError(http.StatusBadRequest, "invalid aggregation", fmt.Sprintf("%s does not support %s", metric.Name, aggregation))
+		}
+	default:
+		a, err := timescale.ParseBucketAggregation(string(aggregation))
+		if err != nil {
+			return nil, "", newProblemError(http.StatusBadRequest, "invalid aggregation", err.Error())
+		}
+
+		if !metric.HasRange() || metric.Circular {
+			return nil, "", newProblemError(http.StatusBadRequest, "invalid aggregation", fmt.Sprintf("%s does not support %s", metric.Name, aggregation))
+		}
+
+		bucketAggregation = a
+		aggregation = catalog.Aggregation(a)
+	}
+
+	serialNumber, perr := lookupSerialNumber(s.devices, metric, q.Device)
+	if perr != nil {
+		return nil, "", perr
+	}
+
+	if aggregation == catalog.AggregationLast {
+		last, err := s.getColumnLast(ctx, metric, timescale.GetColumnLastTemplateParameters{
+			ColumnName:   metric.LatestColumn(),
+			TableName:    metric.Table,
+			SerialNumber: serialNumber,
+		})
+		if err != nil {
+			return nil, "", newProblemError(http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting data for %s: %s", metric.Name, err.Error()))
+		}
+
+		value := metric.Quantity.Convert(system, last.Last)
+
+		return []BatchPoint{{Time: last.Time.In(loc), Value: &value}}, aggregation, nil
+	}
+
+	start := q.Start
+	if q.Range != "" {
+		if start != "" {
+			return nil, "", newProblemError(http.StatusBadRequest, "invalid time range", "range and start are mutually exclusive")
+		}
+		start = "-" + q.Range
+	}
+
+	tr, err := timerange.Parse(start, q.End, time.Now())
+	if err != nil {
+		return nil, "", newProblemError(http.StatusBadRequest, "invalid time range", err.Error())
+	}
+
+	bucket, err := timerange.ParseBucket(q.Bucket, tr)
+	if err != nil {
+		return nil, "", newProblemError(http.StatusBadRequest, "invalid bucket", err.Error())
+	}
+
+	fill, err := timescale.ParseFill(q.Fill)
+	if err != nil {
+		return nil, "", newProblemError(http.StatusBadRequest, "invalid fill", err.Error())
+	}
+
+	if metric.Circular && q.Fill != "" {
+		return nil, "", newProblemError(http.StatusBadRequest, "invalid fill", fmt.Sprintf("%s is vector averaged and does not support fill", metric.Name))
+	}
+
+	tp := timescale.GetColumnTemplateParameters{
+		ColumnName:   metric.Column,
+		TimeBucket:   timerange.FormatInterval(bucket),
+		Start:        tr.Start,
+		End:          tr.End,
+		TableName:    metric.Table,
+		SerialNumber: serialNumber,
+		Fill:         fill,
+		Location:     loc,
+		Relative:     tr.Relative,
+	}
+
+	if metric.Circular {
+		values, err := s.timescaleClient.GetWindDirection(ctx, tp)
+		if err != nil {
+			return nil, "", newProblemError(http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting wind direction: %s", err.Error()))
+		}
+
+		points := make([]BatchPoint, 0, len(values))
+		for _, v := range values {
+			points = append(points, BatchPoint{Time: v.Time.In(loc), Value: v.Direction})
+		}
+
+		return points, aggregation, nil
+	}
+
+	if bucketAggregation != "" {
+		tp.Aggregations = []timescale.BucketAggregation{bucketAggregation}
+		if bucketAggregation == timescale.AggregateSum {
+			tp.Aggregations = append(tp.Aggregations, timescale.AggregateCount)
+		}
+	}
+
+	values, err := s.getColumn(ctx, metric, tp)
+	if err != nil {
+		return nil, "", newProblemError(http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting data for %s: %s", metric.Name, err.Error()))
+	}
+
+	points := make([]BatchPoint, 0, len(values))
+	for _, v := range values {
+		convertBucket(metric.Quantity, system, &v)
+
+		var value *float64
+		switch aggregation {
+		case catalog.AggregationMin:
+			value = v.Min
+		case catalog.AggregationMax:
+			value = v.Max
+		case catalog.AggregationAvg:
+			value = v.Avg
+		default:
+			value = v.Aggregate(bucketAggregation)
+		}
+
+		points = append(points, BatchPoint{Time: v.Time.In(loc), Value: value})
+	}
+
+	return points, aggregation, nil
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+// RainSummaryResponse is the rain accumulated so far in the current day,
+// month, calendar year and water year.
+type RainSummaryResponse struct {
+	Time           time.Time `json:"time"`
+	Today          float64   `json:"today"`
+	Month          float64   `json:"month"`
+	Year           float64   `json:"year"`
+	WaterYear      float64   `json:"water_year"`
+	WaterYearStart time.Time `json:"water_year_start"`
+	Unit           string    `json:"unit"`
+}
+
+func (s *ClimateHandler) getRainAccumulation(w http.ResponseWriter, r *http.Request, tp timescale.GetRainAccumulationTemplateParameters) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
+
+	totals, err := s.timescaleClient.GetRainAccumulation(r.Context(), tp)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting rain accumulation: %s", err.Error()))
+		return
+	}
+
+	unit := units.Precipitation.Symbol(system)
+	for i := range totals {
+		totals[i].Total = units.Precipitation.Convert(system, totals[i].Total)
+		totals[i].Unit = unit
+	}
+
+	writeJSON(w, r, totals, "rain accumulation")
+}
+
+// GetRainDaily serves /rain/daily?start=&end= with the rain total of every
+// local day the range touches, the last 30 days by default.
+func (s *ClimateHandler) GetRainDaily(w http.ResponseWriter, r *http.Request) {
+	tr, ok := s.calendarRange(w, r, defaultDailyLookback)
+	if !ok {
+		return
+	}
+
+	start, ok := s.dailyStart(w, r, tr)
+	if !ok {
+		return
+	}
+
+	s.getRainAccumulation(w, r, timescale.GetRainAccumulationTemplateParameters{
+		Start:    start,
+		End:      tr.End,
+		Period:   timescale.RainPeriodDay,
+		Location: s.location,
+		Relative: tr.Relative,
+	})
+}
+
+// GetRainMonthly serves /rain/monthly?start=&end= with the rain total of every
+// local month the range touches, the last year by default.
+func (s *ClimateHandler) GetRainMonthly(w http.ResponseWriter, r *http.Request) {
+	tr, ok := s.calendarRange(w, r, defaultMonthlyLookback)
+	if !ok {
+		return
+	}
+
+	s.getRainAccumulation(w, r, timescale.GetRainAccumulationTemplateParameters{
+		Start:    timerange.StartOfMonth(tr.Start, s.location),
+		End:      tr.End,
+		Period:   timescale.RainPeriodMonth,
+		Location: s.location,
+		Relative: tr.Relative,
+	})
+}
+
+// GetRainTotal serves /rain/total?start=&end= with the rain total of an
+// arbitrary range, the last 24 hours by default.
+func (s *ClimateHandler) GetRainTotal(w http.ResponseWriter, r *http.Request) {
+	tr, ok := s.calendarRange(w, r, "")
+	if !ok {
+		return
+	}
+
+	s.getRainAccumulation(w, r, timescale.GetRainAccumulationTemplateParameters{
+		Start:    tr.Start,
+		End:      tr.End,
+		Location: s.location,
+		Relative: tr.Relative,
+	})
+}
+
+// GetRainSummary serves /rain/summary with today's, month to date, year to
+// date and water year to date rain totals.
+func (s *ClimateHandler) GetRainSummary(w http.ResponseWriter, r *http.Request) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now().In(s.location)
+	waterYearStart := timerange.StartOfWaterYear(now, s.location)
+
+	totals, err := s.timescaleClient.GetRainSince(r.Context(), timescale.GetRainSinceTemplateParameters{
+		Since: []time.Time{
+			timerange.StartOfDay(now, s.location),
+			timerange.StartOfMonth(now, s.location),
+			timerange.StartOfYear(now, s.location),
+			waterYearStart,
+		},
+		End:      now,
+		Location: s.location,
+	})
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting rain summary: %s", err.Error()))
+		return
+	}
+
+	writeJSON(w, r, RainSummaryResponse{
+		Time:           now,
+		Today:          units.Precipitation.Convert(system, totals[0]),
+		Month:          units.Precipitation.Convert(system, totals[1]),
+		Year:           units.Precipitation.Convert(system, totals[2]),
+		WaterYear:      units.Precipitation.Convert(system, totals[3]),
+		WaterYearStart: waterYearStart,
+		Unit:           units.Precipitation.Symbol(system),
+	}, "rain summary")
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"alpineworks.io/rfc9457"
+)
+
+// problemError is an error that is reported as a problem with its status.
+type problemError struct {
+	status int
+	title  string
+	detail string
+}
+
+func (e *problemError) Error() string {
+	return fmt.Sprintf("%s: %s", e.title, e.detail)
+}
+
+func newProblemError(status int, title string, detail string) *problemError {
+	return &problemError{status: status, title: title, detail: detail}
+}
+
+// problemJSON renders an RFC 9457 problem document about instance.
+func problemJSON(instance string, statusCode int, title string, detail string) (string, error) {
+	problem := rfc9457.NewRFC9457(
+		rfc9457.WithTitle(title),
+		rfc9457.WithDetail(detail),
+		rfc9457.WithInstance(instance),
+		rfc9457.WithStatus(statusCode),
+	)
+
+	return problem.ToJSON()
+}
+
+// writeProblem responds with an RFC 9457 problem document.
+func writeProblem(w http.ResponseWriter, r *http.Request, statusCode int, title string, detail string) {
+	problemJSON, err := problemJSON(r.URL.Path, statusCode, title, detail)
+	if err != nil {
+		slog.Error("failed to marshal problem", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(statusCode)
+
+	_, err = w.Write([]byte(problemJSON))
+	if err != nil {
+		slog.Error("failed to write problem", slog.String("error", err.Error()))
+	}
+}
+
+// writeJSON marshals v and writes it as the response body, describing failures
+// as problems about subject.
+func writeJSON(w http.ResponseWriter, r *http.Request, v any, subject string) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to marshal data", fmt.Sprintf("error marshalling %s: %s", subject, err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(res)
+	if err != nil {
+		slog.Error("failed to write response", slog.String("error", err.Error()))
+	}
+}
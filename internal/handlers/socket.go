@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/stream"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+const (
+	// SocketProtocol is the websocket subprotocol of /socket. Browsers offer
+	// it next to the api key subprotocol, see middleware.APIKeyProtocolPrefix.
+	SocketProtocol = "lfpweather.v1"
+
+	socketWriteWait = 10 * time.Second
+	socketPongWait  = 60 * time.Second
+	// socketPingInterval must be shorter than socketPongWait
+	socketPingInterval = socketPongWait * 9 / 10
+	socketMaxMessage   = 4096
+)
+
+var socketUpgrader = websocket.Upgrader{
+	Subprotocols: []string{SocketProtocol},
+	// clients authenticate with api keys rather than cookies, so dashboards
+	// may connect from any origin
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// SocketRequest is a message from a client, adding the metrics, devices and
+// rolling windows to its subscription or removing them from it.
+type SocketRequest struct {
+	Type    string   `json:"type"`
+	Metrics []string `json:"metrics"`
+	Devices []string `json:"devices"`
+	Windows []string `json:"windows"`
+}
+
+// SocketReading is sent when a subscribed metric has a new latest value.
+type SocketReading struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	StreamReading
+}
+
+// SocketAggregate is sent with every reading of a metric for each subscribed
+// rolling window ending at the reading.
+type SocketAggregate struct {
+	Type   string `json:"type"`
+	Metric string `json:"metric"`
+	Device string `json:"device,omitempty"`
+	Window string `json:"window"`
+	timescale.GetColumnResponse
+}
+
+// SocketSubscription acknowledges a request with the whole subscription.
+type SocketSubscription struct {
+	Type    string   `json:"type"`
+	Metrics []string `json:"metrics"`
+	Devices []string `json:"devices"`
+	Windows []string `json:"windows"`
+}
+
+// SocketError reports a request that could not be applied.
+type SocketError struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// socketSelection is what a connection is subscribed to. It is replaced
+// rather than changed, since the hub reads it while publishing.
+type socketSelection struct {
+	metrics map[string]struct{}
+	// devices selects the devices of per-device metrics, every device when empty
+	devices map[string]struct{}
+	windows map[string]struct{}
+}
+
+func (s *socketSelection) matches(r stream.Reading) bool {
+	if _, ok := s.metrics[r.Metric]; !ok {
+		return false
+	}
+
+	if r.Device == "" || len(s.devices) == 0 {
+		return true
+	}
+
+	_, ok := s.devices[r.Device]
+	return ok
+}
+
+// apply returns the selection after a request, or a problem when the request
+// names something unknown.
+func (s *socketSelection) apply(req SocketRequest, devices catalog.Devices) (*socketSelection, *problemError) {
+	var subscribe bool
+	switch req.Type {
+	case "subscribe":
+		subscribe = true
+	case "unsubscribe":
+	default:
+		return nil, newProblemError(http.StatusBadRequest, "unknown request", fmt.Sprintf("%q is not one of subscribe or unsubscribe", req.Type))
+	}
+
+	next := &socketSelection{
+		metrics: maps.Clone(s.metrics),
+		devices: maps.Clone(s.devices),
+		windows: maps.Clone(s.windows),
+	}
+
+	update := func(set map[string]struct{}, key string) {
+		if subscribe {
+			set[key] = struct{}{}
+		} else {
+			delete(set, key)
+		}
+	}
+
+	for _, name := range req.Metrics {
+		metric, ok := catalog.Lookup(name)
+		if !ok || !metric.HasLast() {
+			return nil, newProblemError(http.StatusBadRequest, "unknown metric", fmt.Sprintf("%s is not a metric with a latest value", name))
+		}
+		update(next.metrics, name)
+	}
+
+	for _, selector := range req.Devices {
+		device, ok := devices.Lookup(selector)
+		if !ok {
+			return nil, newProblemError(http.StatusNotFound, "unknown device", fmt.Sprintf("%s is not a known device", selector))
+		}
+		update(next.devices, device.Name)
+	}
+
+	for _, name := range req.Windows {
+		if !slices.ContainsFunc(catalog.Windows, func(w catalog.Window) bool { return w.Name == name }) {
+			return nil, newProblemError(http.StatusBadRequest, "unknown window", fmt.Sprintf("%s is not one of the catalog windows", name))
+		}
+		update(next.windows, name)
+	}
+
+	return next, nil
+}
+
+func (s *socketSelection) subscription() SocketSubscription {
+	return SocketSubscription{
+		Type:    "subscribed",
+		Metrics: sortedKeys(s.metrics),
+		Devices: sortedKeys(s.devices),
+		Windows: sortedKeys(s.windows),
+	}
+}
+
+// sortedKeys returns the keys of a set, an empty set as an empty list.
+func sortedKeys(set map[string]struct{}) []string {
+	keys := slices.AppendSeq(make([]string, 0, len(set)), maps.Keys(set))
+	slices.Sort(keys)
+
+	return keys
+}
+
+// summarizable reports whether rolling aggregates are computed for a metric,
+// which needs a single column that min, max and a plain average make sense of.
+func summarizable(metric catalog.Metric) bool {
+	return !metric.Derived && !metric.Circular && metric.HasRange()
+}
+
+type SocketHandler struct {
+	timescaleClient *timescale.TimescaleClient
+	hub             *stream.Hub
+	devices         catalog.Devices
+}
+
+func NewSocketHandler(timescaleClient *timescale.TimescaleClient, hub *stream.Hub, devices catalog.Devices) *SocketHandler {
+	return &SocketHandler{
+		timescaleClient: timescaleClient,
+		hub:             hub,
+		devices:         devices,
+	}
+}
+
+// GetSocket serves /socket as a websocket. Clients send SocketRequests to
+// subscribe to metrics, devices and rolling windows, and receive the latest
+// value and aggregates of the whole subscription after each request, then
+// every new reading as it arrives. A client that falls behind misses the
+// intermediate readings and is sent the latest again instead, one that stops
+// reading is disconnected.
+func (h *SocketHandler) GetSocket(w http.ResponseWriter, r *http.Request) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := socketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already responded
+		slog.Debug("failed to upgrade websocket", slog.String("error", err.Error()))
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var selection atomic.Pointer[socketSelection]
+	selection.Store(&socketSelection{
+		metrics: map[string]struct{}{},
+		devices: map[string]struct{}{},
+		windows: map[string]struct{}{},
+	})
+
+	filter := func(reading stream.Reading) bool {
+		return selection.Load().matches(reading)
+	}
+
+	subscription, _ := h.hub.Subscribe(filter, "")
+	defer func() {
+		h.hub.Unsubscribe(subscription)
+	}()
+
+	requests := make(chan SocketRequest)
+	go h.readRequests(ctx, cancel, conn, requests)
+
+	ping := time.NewTicker(socketPingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-requests:
+			next, problem := selection.Load().apply(req, h.devices)
+			if problem != nil {
+				err = h.write(conn, SocketError{Type: "error", Title: problem.title, Detail: problem.detail})
+				break
+			}
+
+			selection.Store(next)
+
+			err = h.write(conn, next.subscription())
+			if err != nil {
+				break
+			}
+
+			err = h.writeEvents(ctx, conn, system, next, h.hub.Latest(next.matches))
+		case e, ok := <-subscription.C:
+			if !ok {
+				// the client fell behind, skip to the latest values
+				var latest []stream.Event
+				subscription, latest = h.hub.Subscribe(filter, "")
+				err = h.writeEvents(ctx, conn, system, selection.Load(), latest)
+				break
+			}
+
+			err = h.writeEvents(ctx, conn, system, selection.Load(), []stream.Event{e})
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait))
+		}
+
+		if err != nil {
+			slog.Debug("failed to write websocket", slog.String("error", err.Error()))
+			return
+		}
+	}
+}
+
+// readRequests reads requests until the connection fails or closes, then
+// cancels the connection.
+func (h *SocketHandler) readRequests(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, requests chan<- SocketRequest) {
+	defer cancel()
+
+	conn.SetReadLimit(socketMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	})
+
+	for {
+		var req SocketRequest
+		err := conn.ReadJSON(&req)
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				slog.Debug("failed to read websocket", slog.String("error", err.Error()))
+			}
+			return
+		}
+
+		select {
+		case requests <- req:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *SocketHandler) write(conn *websocket.Conn, v any) error {
+	err := conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+	if err != nil {
+		return err
+	}
+
+	return conn.WriteJSON(v)
+}
+
+// writeEvents writes each reading followed by its rolling aggregates.
+func (h *SocketHandler) writeEvents(ctx context.Context, conn *websocket.Conn, system units.System, selection *socketSelection, events []stream.Event) error {
+	for _, e := range events {
+		err := h.write(conn, SocketReading{Type: "reading", ID: e.ID(), StreamReading: newStreamReading(e.Reading, system)})
+		if err != nil {
+			return err
+		}
+
+		metric, ok := catalog.Lookup(e.Metric)
+		if !ok || !summarizable(metric) {
+			continue
+		}
+
+		for _, window := range catalog.Windows {
+			if _, ok := selection.windows[window.Name]; !ok {
+				continue
+			}
+
+			aggregate, err := h.aggregate(ctx, system, metric, e.Reading, window)
+			if err != nil {
+				slog.Error("failed to summarize reading", slog.String("metric", metric.Name), slog.String("window", window.Name), slog.String("error", err.Error()))
+				continue
+			}
+
+			err = h.write(conn, aggregate)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// aggregate summarizes a metric over the rolling window ending at a reading.
+func (h *SocketHandler) aggregate(ctx context.Context, system units.System, metric catalog.Metric, reading stream.Reading, window catalog.Window) (SocketAggregate, error) {
+	var serialNumber string
+	if reading.Device != "" {
+		device, ok := h.devices.Lookup(reading.Device)
+		if !ok {
+			return SocketAggregate{}, fmt.Errorf("unknown device %s", reading.Device)
+		}
+		serialNumber = device.SerialNumber
+	}
+
+	summary, err := h.timescaleClient.GetColumnSummary(ctx, timescale.GetColumnSummaryTemplateParameters{
+		ColumnName:   metric.Column,
+		TableName:    metric.Table,
+		SerialNumber: serialNumber,
+		Window:       window.LookbackInterval,
+		End:          reading.Time,
+	})
+	if err != nil {
+		return SocketAggregate{}, err
+	}
+
+	convertBucket(metric.Quantity, system, summary)
+	summary.Unit = metric.UnitIn(system)
+
+	return SocketAggregate{
+		Type:              "aggregate",
+		Metric:            metric.Name,
+		Device:            reading.Device,
+		Window:            window.Name,
+		GetColumnResponse: *summary,
+	}, nil
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/stream"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+const (
+	// sseHeartbeat is how often an idle stream sends a comment, so proxies
+	// keep the connection open and clients notice when it is gone.
+	sseHeartbeat = 15 * time.Second
+	// sseRetry is how long clients wait before reconnecting, in milliseconds.
+	sseRetry = 5000
+)
+
+// StreamReading is a new latest value of a metric.
+type StreamReading struct {
+	Metric string    `json:"metric"`
+	Device string    `json:"device,omitempty"`
+	Time   time.Time `json:"time"`
+	Value  float64   `json:"value"`
+	Unit   string    `json:"unit,omitempty"`
+}
+
+// newStreamReading converts a hub reading into system.
+func newStreamReading(r stream.Reading, system units.System) StreamReading {
+	reading := StreamReading{
+		Metric: r.Metric,
+		Device: r.Device,
+		Time:   r.Time,
+		Value:  r.Value,
+	}
+
+	if metric, ok := catalog.Lookup(r.Metric); ok {
+		reading.Value = metric.Quantity.Convert(system, r.Value)
+		reading.Unit = metric.UnitIn(system)
+	}
+
+	return reading
+}
+
+type StreamHandler struct {
+	hub     *stream.Hub
+	devices catalog.Devices
+}
+
+func NewStreamHandler(hub *stream.Hub, devices catalog.Devices) *StreamHandler {
+	return &StreamHandler{
+		hub:     hub,
+		devices: devices,
+	}
+}
+
+// streamFilter resolves the ?metrics= and ?device= selectors of a stream,
+// writing a problem and returning false when they are invalid. Without
+// ?device= the readings of every device are streamed.
+func streamFilter(w http.ResponseWriter, r *http.Request, devices catalog.Devices) (stream.Filter, bool) {
+	var metrics []string
+	if value := r.URL.Query().Get("metrics"); value != "" {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+
+			metric, ok := catalog.Lookup(name)
+			if !ok || !metric.HasLast() {
+				writeProblem(w, r, http.StatusBadRequest, "unknown metric", fmt.Sprintf("%s is not a metric with a latest value", name))
+				return nil, false
+			}
+
+			metrics = append(metrics, name)
+		}
+	}
+
+	var device string
+	if selector := r.URL.Query().Get("device"); selector != "" {
+		d, ok := devices.Lookup(selector)
+		if !ok {
+			writeProblem(w, r, http.StatusNotFound, "unknown device", fmt.Sprintf("%s is not a known device", selector))
+			return nil, false
+		}
+		device = d.Name
+	}
+
+	if metrics == nil && device == "" {
+		return nil, true
+	}
+
+	return func(reading stream.Reading) bool {
+		if metrics != nil && !slices.Contains(metrics, reading.Metric) {
+			return false
+		}
+
+		return device == "" || reading.Device == "" || reading.Device == device
+	}, true
+}
+
+// GetStream serves /stream as server-sent events, sending a reading event
+// whenever a metric has a new latest value. A client starts with the latest
+// value of every selected metric, or with the events it missed when it
+// reconnects with Last-Event-ID. Clients that fall behind are disconnected
+// and resume when they reconnect.
+func (h *StreamHandler) GetStream(w http.ResponseWriter, r *http.Request) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
+
+	filter, ok := streamFilter(w, r, h.devices)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, r, http.StatusInternalServerError, "streaming unsupported", "the connection cannot be streamed")
+		return
+	}
+
+	subscription, missed := h.hub.Subscribe(filter, r.Header.Get("Last-Event-ID"))
+	defer h.hub.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// keeps nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	_, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry)
+	if err != nil {
+		return
+	}
+
+	for _, e := range missed {
+		err := writeEvent(w, e, system)
+		if err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-subscription.C:
+			if !ok {
+				return
+			}
+
+			err := writeEvent(w, e, system)
+			if err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": heartbeat\n\n")
+			if err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes a reading event.
+func writeEvent(w http.ResponseWriter, e stream.Event, system units.System) error {
+	data, err := json.Marshal(newStreamReading(e.Reading, system))
+	if err != nil {
+		slog.Error("failed to marshal reading", slog.String("error", err.Error()))
+		return nil
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: reading\ndata: %s\n\n", e.ID(), data)
+
+	return err
+}
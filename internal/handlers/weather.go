@@ -1,470 +1,453 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log/slog"
 	"net/http"
-
-	"alpineworks.io/rfc9457"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/derived"
+	"github.com/michaelpeterswa/lfpweather-api/internal/downsample"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
 	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
 )
 
 type WeatherHandler struct {
-	timescaleClient *timescale.TimescaleClient
-}
-
-func NewWeatherHandler(timescaleClient *timescale.TimescaleClient) *WeatherHandler {
-	return &WeatherHandler{timescaleClient: timescaleClient}
+	timescaleClient  *timescale.TimescaleClient
+	derivedClient    *derived.DerivedClient
+	downsampleClient *downsample.DownsampleClient
+	devices          catalog.Devices
+	location         *time.Location
+}
+
+func NewWeatherHandler(timescaleClient *timescale.TimescaleClient, derivedClient *derived.DerivedClient, downsampleClient *downsample.DownsampleClient, devices catalog.Devices, location *time.Location) *WeatherHandler {
+	return &WeatherHandler{
+		timescaleClient:  timescaleClient,
+		derivedClient:    derivedClient,
+		downsampleClient: downsampleClient,
+		devices:          devices,
+		location:         location,
+	}
 }
 
 func (s *WeatherHandler) Close() {
 	s.timescaleClient.Close()
 }
 
-func (s *WeatherHandler) GetColumnGeneric(w http.ResponseWriter, r *http.Request, tp timescale.GetColumnTemplateParameters) {
-	values, err := s.timescaleClient.GetColumn(r.Context(), tp)
+// serialNumber resolves the ?device= selector for a metric, writing a problem
+// and returning false when the selection is invalid.
+func serialNumber(w http.ResponseWriter, r *http.Request, devices catalog.Devices, metric catalog.Metric) (string, bool) {
+	serial, err := lookupSerialNumber(devices, metric, r.URL.Query().Get("device"))
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-
-		problem := rfc9457.NewRFC9457(
-			rfc9457.WithTitle("failed to get 24h data"),
-			rfc9457.WithDetail(fmt.Sprintf("error getting data for column %s: %s", tp.ColumnName, err.Error())),
-			rfc9457.WithInstance(r.URL.Path),
-			rfc9457.WithStatus(statusCode),
-		)
-		w.Header().Set("Content-Type", "application/problem+json")
-		w.WriteHeader(statusCode)
-
-		problemJSON, err := problem.ToJSON()
-		if err != nil {
-			slog.Error("failed to marshal problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		_, err = w.Write([]byte(problemJSON))
-		if err != nil {
-			slog.Error("failed to write problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-		return
+		writeProblem(w, r, err.status, err.title, err.detail)
+		return "", false
 	}
 
-	res, err := json.Marshal(values)
-	if err != nil {
-		statusCode := http.StatusInternalServerError
-
-		problem := rfc9457.NewRFC9457(
-			rfc9457.WithTitle("failed to marshal data"),
-			rfc9457.WithDetail(fmt.Sprintf("error marshalling data for column %s: %s", tp.ColumnName, err.Error())),
-			rfc9457.WithInstance(r.URL.Path),
-			rfc9457.WithStatus(statusCode),
-		)
-		w.Header().Set("Content-Type", "application/problem+json")
-		w.WriteHeader(statusCode)
-
-		problemJSON, err := problem.ToJSON()
-		if err != nil {
-			slog.Error("failed to marshal problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+	return serial, true
+}
 
-		_, err = w.Write([]byte(problemJSON))
-		if err != nil {
-			slog.Error("failed to write problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
+// lookupSerialNumber resolves a device selector for a metric, defaulting to
+// the first device for per device metrics.
+func lookupSerialNumber(devices catalog.Devices, metric catalog.Metric, selector string) (string, *problemError) {
+	if !metric.PerDevice {
+		if selector != "" {
+			return "", newProblemError(http.StatusBadRequest, "invalid device", fmt.Sprintf("%s is not reported per device", metric.Name))
 		}
-		return
+		return "", nil
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_, err = w.Write(res)
-	if err != nil {
-		statusCode := http.StatusInternalServerError
-
-		problem := rfc9457.NewRFC9457(
-			rfc9457.WithTitle("failed to write data"),
-			rfc9457.WithDetail(fmt.Sprintf("error writing data for column %s: %s", tp.ColumnName, err.Error())),
-			rfc9457.WithInstance(r.URL.Path),
-			rfc9457.WithStatus(statusCode),
-		)
-		w.Header().Set("Content-Type", "application/problem+json")
-		w.WriteHeader(statusCode)
-
-		problemJSON, err := problem.ToJSON()
-		if err != nil {
-			slog.Error("failed to marshal problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+	if selector == "" {
+		return devices.Default().SerialNumber, nil
+	}
 
-		_, err = w.Write([]byte(problemJSON))
-		if err != nil {
-			slog.Error("failed to write problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-		}
-		return
+	device, ok := devices.Lookup(selector)
+	if !ok {
+		return "", newProblemError(http.StatusNotFound, "unknown device", fmt.Sprintf("%s is not a known device", selector))
 	}
-}
 
-func (s *WeatherHandler) GetColumn12h(w http.ResponseWriter, r *http.Request, columnName string, tableName string) {
-	s.GetColumnGeneric(w, r, timescale.GetColumnTemplateParameters{
-		ColumnName:       columnName,
-		LookbackInterval: "12h",
-		TimeBucket:       "30m",
-		TableName:        tableName,
-	})
+	return device.SerialNumber, nil
 }
 
-func (s *WeatherHandler) GetColumn24h(w http.ResponseWriter, r *http.Request, columnName string, tableName string) {
-	s.GetColumnGeneric(w, r, timescale.GetColumnTemplateParameters{
-		ColumnName:       columnName,
-		LookbackInterval: "24h",
-		TimeBucket:       "1h",
-		TableName:        tableName,
-	})
-}
+// unitSystem resolves the ?units= selector, writing a problem and returning
+// false when it is invalid.
+func unitSystem(w http.ResponseWriter, r *http.Request) (units.System, bool) {
+	system, err := units.ParseSystem(r.URL.Query().Get("units"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid units", err.Error())
+		return "", false
+	}
 
-func (s *WeatherHandler) GetColumn7d(w http.ResponseWriter, r *http.Request, columnName string, tableName string) {
-	s.GetColumnGeneric(w, r, timescale.GetColumnTemplateParameters{
-		ColumnName:       columnName,
-		LookbackInterval: "7d",
-		TimeBucket:       "6h",
-		TableName:        tableName,
-	})
+	return system, true
 }
 
-func (s *WeatherHandler) GetColumn30d(w http.ResponseWriter, r *http.Request, columnName string, tableName string) {
-	s.GetColumnGeneric(w, r, timescale.GetColumnTemplateParameters{
-		ColumnName:       columnName,
-		LookbackInterval: "30d",
-		TimeBucket:       "1d",
-		TableName:        tableName,
-	})
-}
+// timezone resolves the ?tz= selector, an iana time zone buckets are aligned
+// to, defaulting to the station's. It writes a problem and returns false when
+// the zone is unknown.
+func timezone(w http.ResponseWriter, r *http.Request, station *time.Location) (*time.Location, bool) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		return station, true
+	}
 
-// 12h
+	loc, err := loadLocation(name)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid time zone", err.Error())
+		return nil, false
+	}
 
-func (s *WeatherHandler) GetTemperature12h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "temperature", "vantagepro2plus")
+	return loc, true
 }
 
-func (s *WeatherHandler) GetHumidity12h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "humidity", "vantagepro2plus")
-}
+// loadLocation loads an iana time zone by name, rejecting Local, which names
+// the server's zone rather than one the database knows.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, fmt.Errorf("%s is not an iana time zone", name)
+	}
 
-func (s *WeatherHandler) GetPressure12h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "barometer_sea_level", "vantagepro2plus")
-}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not an iana time zone", name)
+	}
 
-func (s *WeatherHandler) GetSolarRadiation12h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "solar_radiation", "vantagepro2plus")
+	return loc, nil
 }
 
-func (s *WeatherHandler) GetWindSpeedLast12h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "wind_speed_last", "vantagepro2plus")
-}
+// strictFreshness resolves the ?strict= selector, writing a problem and
+// returning false when it is invalid.
+func strictFreshness(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	value := r.URL.Query().Get("strict")
+	if value == "" {
+		return false, true
+	}
 
-func (s *WeatherHandler) GetRainRateLast12h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "rain_rate_last", "vantagepro2plus")
-}
+	strict, err := strconv.ParseBool(value)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid strict", fmt.Sprintf("%s is not a boolean", value))
+		return false, false
+	}
 
-func (s *WeatherHandler) GetUVIndex12h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "uv_index", "vantagepro2plus")
+	return strict, true
 }
 
-func (s *WeatherHandler) GetAQI12h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "aqi", "airgradient_aqi")
+// writeStaleProblem responds that the latest readings of metrics are too old.
+func writeStaleProblem(w http.ResponseWriter, r *http.Request, metrics []string, age time.Duration) {
+	writeProblem(w, r, http.StatusServiceUnavailable, "stale data", fmt.Sprintf("the latest reading of %s is %s old", strings.Join(metrics, ", "), age.Truncate(time.Second)))
 }
 
-func (s *WeatherHandler) GetCo212h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "rco2", "airgradient")
-}
+// getColumn reads a metric from its column or, for derived metrics, computes it.
+func (s *WeatherHandler) getColumn(ctx context.Context, metric catalog.Metric, tp timescale.GetColumnTemplateParameters) ([]timescale.GetColumnResponse, error) {
+	if !metric.Derived {
+		return s.timescaleClient.GetColumn(ctx, tp)
+	}
 
-func (s *WeatherHandler) GetNoxIndex12h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "nox_index", "airgradient")
-}
+	derivation, ok := derived.Lookup(metric.Name)
+	if !ok {
+		return nil, fmt.Errorf("no derivation for %s", metric.Name)
+	}
 
-func (s *WeatherHandler) GetTvocIndex12h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn12h(w, r, "tvoc_index", "airgradient")
+	return s.derivedClient.GetColumn(ctx, derivation, tp)
 }
 
-// 24h
-
-func (s *WeatherHandler) GetTemperature24h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "temperature", "vantagepro2plus")
-}
+// getColumnLast is getColumn for the latest value.
+func (s *WeatherHandler) getColumnLast(ctx context.Context, metric catalog.Metric, tp timescale.GetColumnLastTemplateParameters) (*timescale.GetColumnLastResponse, error) {
+	if !metric.Derived {
+		return s.timescaleClient.GetColumnLast(ctx, tp)
+	}
 
-func (s *WeatherHandler) GetHumidity24h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "humidity", "vantagepro2plus")
-}
+	derivation, ok := derived.Lookup(metric.Name)
+	if !ok {
+		return nil, fmt.Errorf("no derivation for %s", metric.Name)
+	}
 
-func (s *WeatherHandler) GetPressure24h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "barometer_sea_level", "vantagepro2plus")
+	return s.derivedClient.GetColumnLast(ctx, derivation, tp)
 }
 
-func (s *WeatherHandler) GetSolarRadiation24h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "solar_radiation", "vantagepro2plus")
-}
+func (s *WeatherHandler) GetColumnGeneric(w http.ResponseWriter, r *http.Request, metric catalog.Metric, tp timescale.GetColumnTemplateParameters) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
 
-func (s *WeatherHandler) GetWindSpeedLast24h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "wind_speed_last", "vantagepro2plus")
-}
+	format, ok := responseFormat(w, r)
+	if !ok {
+		return
+	}
 
-func (s *WeatherHandler) GetRainRateLast24h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "rain_rate_last", "vantagepro2plus")
-}
+	aggregations, err := timescale.ParseBucketAggregations(r.URL.Query().Get("agg"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid aggregation", err.Error())
+		return
+	}
 
-func (s *WeatherHandler) GetUVIndex24h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "uv_index", "vantagepro2plus")
-}
+	tp.Location, ok = timezone(w, r, s.location)
+	if !ok {
+		return
+	}
 
-func (s *WeatherHandler) GetAQI24h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "aqi", "airgradient_aqi")
-}
+	fill := r.URL.Query().Get("fill")
 
-func (s *WeatherHandler) GetCo224h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "rco2", "airgradient")
-}
+	tp.Fill, err = timescale.ParseFill(fill)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid fill", err.Error())
+		return
+	}
 
-func (s *WeatherHandler) GetNoxIndex24h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "nox_index", "airgradient")
-}
+	points, ok := downsamplePoints(w, r)
+	if !ok {
+		return
+	}
 
-func (s *WeatherHandler) GetTvocIndex24h(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn24h(w, r, "tvoc_index", "airgradient")
-}
+	if metric.Circular {
+		if len(aggregations) > 0 || points > 0 || fill != "" {
+			writeProblem(w, r, http.StatusBadRequest, "invalid aggregation", fmt.Sprintf("%s is vector averaged and does not support ?agg=, ?points= or ?fill=", metric.Name))
+			return
+		}
 
-// 7d
+		s.getWindDirection(w, r, system, tp, format)
+		return
+	}
 
-func (s *WeatherHandler) GetTemperature7d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "temperature", "vantagepro2plus")
-}
+	if points > 0 {
+		if len(aggregations) > 0 || fill != "" {
+			writeProblem(w, r, http.StatusBadRequest, "invalid aggregation", "?points= returns raw readings and cannot be combined with ?agg= or ?fill=")
+			return
+		}
 
-func (s *WeatherHandler) GetHumidity7d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "humidity", "vantagepro2plus")
-}
+		s.getColumnDownsampled(w, r, system, metric, tp, points, format)
+		return
+	}
 
-func (s *WeatherHandler) GetPressure7d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "barometer_sea_level", "vantagepro2plus")
-}
+	// sums of temperatures are converted with the number of readings summed
+	countForSum := slices.Contains(aggregations, timescale.AggregateSum) && !slices.Contains(aggregations, timescale.AggregateCount)
+	tp.Aggregations = aggregations
+	if countForSum {
+		tp.Aggregations = append(slices.Clone(aggregations), timescale.AggregateCount)
+	}
 
-func (s *WeatherHandler) GetSolarRadiation7d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "solar_radiation", "vantagepro2plus")
-}
+	if format == formatCSV {
+		s.getColumnCSV(w, r, system, metric, tp, aggregations)
+		return
+	}
 
-func (s *WeatherHandler) GetWindSpeedLast7d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "wind_speed_last", "vantagepro2plus")
-}
+	values, err := s.getColumn(r.Context(), metric, tp)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting data for %s: %s", metric.Name, err.Error()))
+		return
+	}
 
-func (s *WeatherHandler) GetRainRateLast7d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "rain_rate_last", "vantagepro2plus")
-}
+	unit := metric.UnitIn(system)
+	for i := range values {
+		convertBucket(metric.Quantity, system, &values[i])
+		values[i].Time = values[i].Time.In(tp.Location)
+		values[i].Unit = unit
 
-func (s *WeatherHandler) GetUVIndex7d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "uv_index", "vantagepro2plus")
-}
+		if countForSum {
+			values[i].Count = nil
+		}
+	}
 
-func (s *WeatherHandler) GetAQI7d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "aqi", "airgradient_aqi")
+	writeJSON(w, r, values, fmt.Sprintf("data for %s", metric.Name))
 }
 
-func (s *WeatherHandler) GetCo27d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "rco2", "airgradient")
-}
+// getColumnCSV writes a ranged query as csv with a column per aggregation.
+// Native metrics are streamed from the database as they are read.
+func (s *WeatherHandler) getColumnCSV(w http.ResponseWriter, r *http.Request, system units.System, metric catalog.Metric, tp timescale.GetColumnTemplateParameters, aggregations []timescale.BucketAggregation) {
+	unit := metric.UnitIn(system)
 
-func (s *WeatherHandler) GetNoxIndex7d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "nox_index", "airgradient")
-}
+	header := []string{"time", csvColumn("avg", unit), csvColumn("min", unit), csvColumn("max", unit)}
+	for _, a := range aggregations {
+		if a == timescale.AggregateCount {
+			header = append(header, string(a))
+		} else {
+			header = append(header, csvColumn(string(a), unit))
+		}
+	}
 
-func (s *WeatherHandler) GetTvocIndex7d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn7d(w, r, "tvoc_index", "airgradient")
-}
+	res := newCSVResponse(w, metric.Name+".csv", header)
 
-// 30d
+	write := func(b timescale.GetColumnResponse) error {
+		convertBucket(metric.Quantity, system, &b)
 
-func (s *WeatherHandler) GetTemperature30d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "temperature", "vantagepro2plus")
-}
+		record := []string{csvTime(b.Time.In(tp.Location)), csvNullable(b.Avg), csvNullable(b.Min), csvNullable(b.Max)}
+		for _, a := range aggregations {
+			record = append(record, csvNullable(b.Aggregate(a)))
+		}
 
-func (s *WeatherHandler) GetHumidity30d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "humidity", "vantagepro2plus")
-}
+		return res.write(record)
+	}
 
-func (s *WeatherHandler) GetPressure30d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "barometer_sea_level", "vantagepro2plus")
-}
+	var err error
+	if metric.Derived {
+		var values []timescale.GetColumnResponse
+		values, err = s.getColumn(r.Context(), metric, tp)
+		for _, v := range values {
+			err = write(v)
+			if err != nil {
+				break
+			}
+		}
+	} else {
+		err = s.timescaleClient.StreamColumn(r.Context(), tp, write)
+	}
 
-func (s *WeatherHandler) GetSolarRadiation30d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "solar_radiation", "vantagepro2plus")
+	res.finish(r, err, fmt.Sprintf("data for %s", metric.Name))
 }
 
-func (s *WeatherHandler) GetWindSpeedLast30d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "wind_speed_last", "vantagepro2plus")
-}
+// convertBucket converts every aggregate of a bucket into system s.
+func convertBucket(q units.Quantity, s units.System, b *timescale.GetColumnResponse) {
+	convert(q, s, b.Avg)
+	convert(q, s, b.Min)
+	convert(q, s, b.Max)
 
-func (s *WeatherHandler) GetRainRateLast30d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "rain_rate_last", "vantagepro2plus")
-}
+	if b.Sum != nil {
+		var n int64
+		if b.Count != nil {
+			n = *b.Count
+		}
+		*b.Sum = q.ConvertSum(s, *b.Sum, n)
+	}
 
-func (s *WeatherHandler) GetUVIndex30d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "uv_index", "vantagepro2plus")
-}
+	if b.Stddev != nil {
+		*b.Stddev = q.ConvertDifference(s, *b.Stddev)
+	}
 
-func (s *WeatherHandler) GetAQI30d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "aqi", "airgradient_aqi")
-}
+	convert(q, s, b.Median)
+	convert(q, s, b.First)
+	convert(q, s, b.Last)
 
-func (s *WeatherHandler) GetCo230d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "rco2", "airgradient")
+	for _, p := range b.Percentiles {
+		convert(q, s, p)
+	}
 }
 
-func (s *WeatherHandler) GetNoxIndex30d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "nox_index", "airgradient")
-}
+// GetColumnWindow returns the handler for one of the fixed catalog windows.
+func (s *WeatherHandler) GetColumnWindow(metric catalog.Metric, window catalog.Window) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serialNumber, ok := serialNumber(w, r, s.devices, metric)
+		if !ok {
+			return
+		}
 
-func (s *WeatherHandler) GetTvocIndex30d(w http.ResponseWriter, r *http.Request) {
-	s.GetColumn30d(w, r, "tvoc_index", "airgradient")
+		s.GetColumnGeneric(w, r, metric, timescale.GetColumnTemplateParameters{
+			ColumnName:       metric.Column,
+			LookbackInterval: window.LookbackInterval,
+			TimeBucket:       window.TimeBucket,
+			TableName:        metric.Table,
+			SerialNumber:     serialNumber,
+		})
+	}
 }
 
-// -------------
+// GetColumnRange serves /{metric}?start=&end=&bucket=&agg= where start and end
+// are RFC3339 timestamps or relative durations, bucket is chosen automatically
+// when omitted and agg adds comma separated aggregations such as count or p95.
+// Every bucket of the range is returned, buckets without readings are null
+// unless ?fill=locf or ?fill=linear fills them. Buckets are aligned to the
+// wall clock of ?tz=, the station's zone by default, and start in that zone.
+// With ?points=N the range is instead downsampled to at most N raw readings.
+func (s *WeatherHandler) GetColumnRange(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["metric"]
 
-func (s *WeatherHandler) GetColumnLastGeneric(w http.ResponseWriter, r *http.Request, tp timescale.GetColumnLastTemplateParameters) {
-	temperatures, err := s.timescaleClient.GetColumnLast(r.Context(), tp)
-	if err != nil {
-		statusCode := http.StatusInternalServerError
-
-		problem := rfc9457.NewRFC9457(
-			rfc9457.WithTitle("failed to get data"),
-			rfc9457.WithDetail(fmt.Sprintf("error getting data for column %s: %s", tp.ColumnName, err.Error())),
-			rfc9457.WithInstance(r.URL.Path),
-			rfc9457.WithStatus(statusCode),
-		)
-		w.Header().Set("Content-Type", "application/problem+json")
-		w.WriteHeader(statusCode)
-
-		problemJSON, err := problem.ToJSON()
-		if err != nil {
-			slog.Error("failed to marshal problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+	metric, ok := catalog.Lookup(name)
+	if !ok || !metric.HasRange() {
+		writeProblem(w, r, http.StatusNotFound, "unknown metric", fmt.Sprintf("%s is not a known metric", name))
+		return
+	}
 
-		_, err = w.Write([]byte(problemJSON))
-		if err != nil {
-			slog.Error("failed to write problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+	serialNumber, ok := serialNumber(w, r, s.devices, metric)
+	if !ok {
 		return
 	}
 
-	res, err := json.Marshal(temperatures)
-	if err != nil {
-		statusCode := http.StatusInternalServerError
-
-		problem := rfc9457.NewRFC9457(
-			rfc9457.WithTitle("failed to marshal 24h data"),
-			rfc9457.WithDetail(fmt.Sprintf("error marshalling data for column %s: %s", tp.ColumnName, err.Error())),
-			rfc9457.WithInstance(r.URL.Path),
-			rfc9457.WithStatus(statusCode),
-		)
-		w.Header().Set("Content-Type", "application/problem+json")
-		w.WriteHeader(statusCode)
-
-		problemJSON, err := problem.ToJSON()
-		if err != nil {
-			slog.Error("failed to marshal problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+	query := r.URL.Query()
 
-		_, err = w.Write([]byte(problemJSON))
-		if err != nil {
-			slog.Error("failed to write problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+	tr, err := timerange.Parse(query.Get("start"), query.Get("end"), time.Now())
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid time range", err.Error())
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_, err = w.Write(res)
+	bucket, err := timerange.ParseBucket(query.Get("bucket"), tr)
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-
-		problem := rfc9457.NewRFC9457(
-			rfc9457.WithTitle("failed to write data"),
-			rfc9457.WithDetail(fmt.Sprintf("error writing data for column %s: %s", tp.ColumnName, err.Error())),
-			rfc9457.WithInstance(r.URL.Path),
-			rfc9457.WithStatus(statusCode),
-		)
-		w.Header().Set("Content-Type", "application/problem+json")
-		w.WriteHeader(statusCode)
-
-		problemJSON, err := problem.ToJSON()
-		if err != nil {
-			slog.Error("failed to marshal problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		_, err = w.Write([]byte(problemJSON))
-		if err != nil {
-			slog.Error("failed to write problem", slog.String("error", err.Error()))
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+		writeProblem(w, r, http.StatusBadRequest, "invalid bucket", err.Error())
 		return
 	}
-}
 
-func (s *WeatherHandler) GetColumnLast(w http.ResponseWriter, r *http.Request, columnName string, tableName string) {
-	s.GetColumnLastGeneric(w, r, timescale.GetColumnLastTemplateParameters{
-		ColumnName: columnName,
-		TableName:  tableName,
+	s.GetColumnGeneric(w, r, metric, timescale.GetColumnTemplateParameters{
+		ColumnName:   metric.Column,
+		TimeBucket:   timerange.FormatInterval(bucket),
+		Start:        tr.Start,
+		End:          tr.End,
+		TableName:    metric.Table,
+		SerialNumber: serialNumber,
+		Relative:     tr.Relative,
 	})
 }
 
-func (s *WeatherHandler) GetTemperatureLast(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "temperature", "vantagepro2plus")
-}
+// -------------
 
-func (s *WeatherHandler) GetHumidityLast(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "humidity", "vantagepro2plus")
-}
+func (s *WeatherHandler) GetColumnLastGeneric(w http.ResponseWriter, r *http.Request, metric catalog.Metric, tp timescale.GetColumnLastTemplateParameters) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
 
-func (s *WeatherHandler) GetPressureLast(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "barometer_sea_level", "vantagepro2plus")
-}
+	strict, ok := strictFreshness(w, r)
+	if !ok {
+		return
+	}
 
-func (s *WeatherHandler) GetSolarRadiationLast(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "solar_radiation", "vantagepro2plus")
-}
+	format, ok := responseFormat(w, r)
+	if !ok {
+		return
+	}
 
-func (s *WeatherHandler) GetWindSpeedHighLast10MinLast(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "wind_speed_high_last_10_min", "vantagepro2plus")
-}
+	last, err := s.getColumnLast(r.Context(), metric, tp)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting data for %s: %s", metric.Name, err.Error()))
+		return
+	}
 
-func (s *WeatherHandler) GetRainLast24hLast(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "rain_last_24_hour", "vantagepro2plus")
-}
+	age, stale := metric.Age(last.Time, time.Now())
+	if stale && strict {
+		writeStaleProblem(w, r, []string{metric.Name}, age)
+		return
+	}
 
-func (s *WeatherHandler) GetUVIndexLast(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "uv_index", "vantagepro2plus")
-}
+	last.Last = metric.Quantity.Convert(system, last.Last)
+	last.Unit = metric.UnitIn(system)
+	last.AgeSeconds = int64(age.Seconds())
+	last.Stale = stale
 
-func (s *WeatherHandler) GetAQILast(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "aqi", "airgradient_aqi")
-}
+	if format == formatCSV {
+		res := newCSVResponse(w, metric.Name+"-last.csv", []string{"time", csvColumn(metric.Name, last.Unit), "age_seconds", "stale"})
+		err := res.write([]string{csvTime(last.Time), csvFloat(last.Last), strconv.FormatInt(last.AgeSeconds, 10), strconv.FormatBool(last.Stale)})
+		res.finish(r, err, fmt.Sprintf("data for %s", metric.Name))
+		return
+	}
 
-func (s *WeatherHandler) GetCo2Last(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "rco2", "airgradient")
+	writeJSON(w, r, last, fmt.Sprintf("data for %s", metric.Name))
 }
 
-func (s *WeatherHandler) GetNoxIndexLast(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "nox_index", "airgradient")
-}
+// GetColumnLast returns the handler for the latest value of a catalog metric.
+func (s *WeatherHandler) GetColumnLast(metric catalog.Metric) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serialNumber, ok := serialNumber(w, r, s.devices, metric)
+		if !ok {
+			return
+		}
 
-func (s *WeatherHandler) GetTvocIndexLast(w http.ResponseWriter, r *http.Request) {
-	s.GetColumnLast(w, r, "tvoc_index", "airgradient")
+		s.GetColumnLastGeneric(w, r, metric, timescale.GetColumnLastTemplateParameters{
+			ColumnName:   metric.LatestColumn(),
+			TableName:    metric.Table,
+			SerialNumber: serialNumber,
+		})
+	}
 }
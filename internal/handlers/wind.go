@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+// maxWindRoseRange bounds the lookback of a wind rose.
+const maxWindRoseRange = 366 * 24 * time.Hour
+
+// getWindDirection serves ranged wind direction, which is vector averaged
+// rather than aggregated with avg/min/max.
+func (s *WeatherHandler) getWindDirection(w http.ResponseWriter, r *http.Request, system units.System, tp timescale.GetColumnTemplateParameters, format outputFormat) {
+	values, err := s.timescaleClient.GetWindDirection(r.Context(), tp)
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting wind direction: %s", err.Error()))
+		return
+	}
+
+	speedUnit := units.Speed.Symbol(system)
+	for i := range values {
+		values[i].Time = values[i].Time.In(tp.Location)
+		if values[i].Speed != nil {
+			speed := units.Speed.Convert(system, *values[i].Speed)
+			values[i].Speed = &speed
+		}
+		values[i].Unit = "°"
+		values[i].SpeedUnit = speedUnit
+	}
+
+	if format == formatCSV {
+		res := newCSVResponse(w, "wind_direction.csv", []string{"time", csvColumn("direction", "°"), csvColumn("speed", speedUnit), "steadiness"})
+		for _, v := range values {
+			err = res.write([]string{csvTime(v.Time), csvNullable(v.Direction), csvNullable(v.Speed), csvNullable(v.Steadiness)})
+			if err != nil {
+				break
+			}
+		}
+		res.finish(r, err, "wind direction")
+		return
+	}
+
+	writeJSON(w, r, values, "wind direction")
+}
+
+// GetWindRose serves /wind/rose?range= with readings counted by 16 compass
+// sectors and speed bins over the lookback range, 24h by default.
+func (s *WeatherHandler) GetWindRose(w http.ResponseWriter, r *http.Request) {
+	system, ok := unitSystem(w, r)
+	if !ok {
+		return
+	}
+
+	lookback := r.URL.Query().Get("range")
+	if lookback == "" {
+		lookback = "24h"
+	}
+
+	d, err := timerange.ParseDuration(lookback)
+	if err != nil || d <= 0 || d > maxWindRoseRange {
+		writeProblem(w, r, http.StatusBadRequest, "invalid range", fmt.Sprintf("%s is not a duration between 0 and 366d", lookback))
+		return
+	}
+
+	rose, err := s.timescaleClient.GetWindRose(r.Context(), timescale.GetWindRoseTemplateParameters{
+		LookbackInterval: timerange.FormatInterval(d),
+	})
+	if err != nil {
+		writeProblem(w, r, http.StatusInternalServerError, "failed to get data", fmt.Sprintf("error getting wind rose: %s", err.Error()))
+		return
+	}
+
+	for i := range rose.SpeedBins {
+		rose.SpeedBins[i] = units.Speed.Convert(system, rose.SpeedBins[i])
+	}
+	rose.SpeedUnit = units.Speed.Symbol(system)
+
+	writeJSON(w, r, rose, "wind rose")
+}
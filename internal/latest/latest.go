@@ -0,0 +1,164 @@
+// Package latest reads the latest value of every catalog metric, grouping the
+// metrics by table so that each table is read once.
+package latest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/derived"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+)
+
+// Group is the metrics read from the latest row of one table. Row and Err
+// are set by Fetch, and Row is nil while the table has no readings, e.g. for
+// a device that has not reported yet.
+type Group struct {
+	Table        string
+	SerialNumber string
+	Device       string
+	Columns      []string
+	Metrics      []catalog.Metric
+
+	Row *timescale.GetColumnsResponse
+	Err error
+}
+
+// addColumn adds a column to the group while it is built by Groups.
+func (g *Group) addColumn(name string) {
+	if _, ok := g.index(name); !ok {
+		g.Columns = append(g.Columns, name)
+	}
+}
+
+// index returns the index of a column in the group's row, false when the
+// group does not read it.
+func (g *Group) index(name string) (int, bool) {
+	for i, c := range g.Columns {
+		if c == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// reading returns a column of the group's row without changing the group,
+// false when the group does not read it.
+func (g *Group) reading(name string) (*float64, bool) {
+	i, ok := g.index(name)
+	if !ok || i >= len(g.Row.Values) {
+		return nil, false
+	}
+
+	return g.Row.Values[i], true
+}
+
+// Value reads a metric from the group's row, computing derived metrics from
+// the columns they are derived from. It is nil when the row has no reading or
+// the group does not read the metric, and it never changes the group.
+func (g *Group) Value(metric catalog.Metric) *float64 {
+	if g.Row == nil {
+		return nil
+	}
+
+	if !metric.Derived {
+		v, _ := g.reading(metric.LatestColumn())
+		return v
+	}
+
+	derivation, ok := derived.Lookup(metric.Name)
+	if !ok {
+		return nil
+	}
+
+	values := make([]float64, 0, len(derivation.Columns))
+	for _, column := range derivation.Columns {
+		v, ok := g.reading(column)
+		if !ok || v == nil {
+			return nil
+		}
+		values = append(values, *v)
+	}
+
+	v := derivation.Compute(values)
+	return &v
+}
+
+// Groups groups the metrics with a latest value by table. Tables shared by
+// several devices get a group per device.
+func Groups(devices ...catalog.Device) ([]*Group, error) {
+	var groups []*Group
+	byTable := map[string]*Group{}
+
+	for _, metric := range catalog.Metrics {
+		if !metric.HasLast() {
+			continue
+		}
+
+		var columns []string
+		if !metric.Derived {
+			columns = []string{metric.LatestColumn()}
+		} else {
+			derivation, ok := derived.Lookup(metric.Name)
+			if !ok {
+				return nil, fmt.Errorf("no derivation for %s", metric.Name)
+			}
+			columns = derivation.Columns
+		}
+
+		var deviceGroups []catalog.Device
+		if metric.PerDevice {
+			deviceGroups = devices
+		} else {
+			deviceGroups = []catalog.Device{{}}
+		}
+
+		for _, device := range deviceGroups {
+			key := metric.Table + "/" + device.SerialNumber
+
+			g, ok := byTable[key]
+			if !ok {
+				g = &Group{Table: metric.Table, SerialNumber: device.SerialNumber, Device: device.Name}
+				byTable[key] = g
+				groups = append(groups, g)
+			}
+
+			for _, column := range columns {
+				g.addColumn(column)
+			}
+
+			g.Metrics = append(g.Metrics, metric)
+		}
+	}
+
+	return groups, nil
+}
+
+// Fetch reads the latest row of every group concurrently. A table without
+// readings is not an error, it leaves Row nil.
+func Fetch(ctx context.Context, timescaleClient *timescale.TimescaleClient, groups []*Group) {
+	var wg sync.WaitGroup
+
+	for _, g := range groups {
+		wg.Add(1)
+		go func(g *Group) {
+			defer wg.Done()
+
+			g.Row, g.Err = timescaleClient.GetColumnsLast(ctx, timescale.GetColumnsLastTemplateParameters{
+				ColumnNames:  g.Columns,
+				TableName:    g.Table,
+				SerialNumber: g.SerialNumber,
+			})
+			if errors.Is(g.Err, pgx.ErrNoRows) {
+				g.Row, g.Err = nil, nil
+			}
+		}(g)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,42 @@
+package latest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+)
+
+func TestValue(t *testing.T) {
+	temperature, ok := catalog.Lookup("temperature")
+	if !ok {
+		t.Fatal("expected a temperature metric")
+	}
+
+	dewPoint, ok := catalog.Lookup("dew_point")
+	if !ok {
+		t.Fatal("expected a dew_point metric")
+	}
+
+	reading := 70.0
+	g := &Group{
+		Table:   "vantagepro2plus",
+		Columns: []string{"temperature"},
+		Row:     &timescale.GetColumnsResponse{Time: time.Now(), Values: []*float64{&reading}},
+	}
+
+	if v := g.Value(temperature); v == nil || *v != reading {
+		t.Errorf("expected %f, got %v", reading, v)
+	}
+
+	// dew point also needs humidity, which the group does not read
+	if v := g.Value(dewPoint); v != nil {
+		t.Errorf("expected no value for a metric the group does not read, got %f", *v)
+	}
+
+	if !slices.Equal(g.Columns, []string{"temperature"}) {
+		t.Errorf("expected reading values to leave the columns alone, got %v", g.Columns)
+	}
+}
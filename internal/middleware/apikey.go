@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"alpineworks.io/rfc9457"
 )
@@ -36,21 +37,45 @@ const (
 	AuthenticationModeAPIKey AuthenticationMode = iota
 )
 
+// APIKeyProtocolPrefix prefixes the api key when it is offered as a websocket
+// subprotocol, since browsers cannot set headers on websocket requests.
+const APIKeyProtocolPrefix = "apikey."
+
+// requestAPIKey returns the X-API-Key header, or for websocket requests
+// without it, the subprotocol starting with APIKeyProtocolPrefix.
+func requestAPIKey(r *http.Request) string {
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		return apiKey
+	}
+
+	for _, value := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(value, ",") {
+			if apiKey, ok := strings.CutPrefix(strings.TrimSpace(protocol), APIKeyProtocolPrefix); ok {
+				return apiKey
+			}
+		}
+	}
+
+	return ""
+}
+
+func (amc *AuthenticationMiddlewareClient) validAPIKey(apiKey string) bool {
+	for _, key := range amc.APIKeys {
+		if apiKey == key {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (amc *AuthenticationMiddlewareClient) AuthenticationMiddleware(next http.Handler) http.Handler {
 	switch amc.Mode {
 	case AuthenticationModeAPIKey:
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			apiKey := r.Header.Get("X-API-Key")
-
-			valid := false
-			for _, key := range amc.APIKeys {
-				if apiKey == key {
-					valid = true
-					break
-				}
-			}
+			apiKey := requestAPIKey(r)
 
-			if !valid {
+			if !amc.validAPIKey(apiKey) {
 				problem := rfc9457.NewRFC9457(
 					rfc9457.WithTitle("invalid api key"),
 					rfc9457.WithDetail(fmt.Sprintf("%s is not a valid api key", apiKey)),
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyMetadataKey is the grpc metadata key carrying the api key, the
+// counterpart of the X-API-Key header.
+const APIKeyMetadataKey = "x-api-key"
+
+// authenticate checks the api key in the metadata of a grpc call.
+func (amc *AuthenticationMiddlewareClient) authenticate(ctx context.Context) error {
+	switch amc.Mode {
+	case AuthenticationModeAPIKey:
+		var apiKey string
+		if values := metadata.ValueFromIncomingContext(ctx, APIKeyMetadataKey); len(values) > 0 {
+			apiKey = values[0]
+		}
+
+		if !amc.validAPIKey(apiKey) {
+			return status.Errorf(codes.Unauthenticated, "%s is not a valid api key", apiKey)
+		}
+
+		return nil
+	default:
+		return status.Error(codes.Internal, "authentication middleware is misconfigured")
+	}
+}
+
+// UnaryServerInterceptor authenticates unary grpc calls like
+// AuthenticationMiddleware authenticates http requests.
+func (amc *AuthenticationMiddlewareClient) UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	err := amc.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor authenticates streaming grpc calls, reflection
+// included.
+func (amc *AuthenticationMiddlewareClient) StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := amc.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
@@ -0,0 +1,9 @@
+window.ui = SwaggerUIBundle({
+  // the document is served next to this page
+  url: new URL("openapi.json", window.location.href).toString(),
+  dom_id: "#docs",
+  deepLinking: true,
+  persistAuthorization: true,
+  // documents are not sent to the public validator
+  validatorUrl: null,
+});
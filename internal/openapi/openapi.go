@@ -0,0 +1,301 @@
+// Package openapi describes the http api as an OpenAPI 3.1 document. Paths
+// are read from the routes of a router and schemas are reflected from the go
+// types of request and response bodies, so the document follows the code.
+package openapi
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gorilla/mux"
+)
+
+// Version is the OpenAPI version of the documents built here.
+const Version = "3.1.0"
+
+// DocsPage is an html page rendering the document served at /openapi.json
+// with DocsAssets, which are served below /docs/.
+//
+//go:embed docs.html
+var DocsPage []byte
+
+//go:embed docs/docs.js docs/swagger-ui-bundle.js docs/swagger-ui.css
+var docsAssets embed.FS
+
+// DocsAssets are the script of DocsPage and the swagger-ui-dist 5.18.2 files
+// it renders with, vendored so the docs load nothing from other origins.
+var DocsAssets, _ = fs.Sub(docsAssets, "docs")
+
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+
+	// names are the component names of reflected types
+	names map[reflect.Type]string
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path by lowercase method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a parameter, or with Ref a reference to one of the components.
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	In          string  `json:"in,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response is a response, or with Ref a reference to one of the components.
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	Parameters      map[string]*Parameter      `json:"parameters,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name,omitempty"`
+	In          string `json:"in,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// New returns an empty document. The RFC 9457 problem every route responds
+// with on errors is part of its components, see ProblemResponse.
+func New(info Info) *Document {
+	d := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas:    map[string]*Schema{},
+			Parameters: map[string]*Parameter{},
+			Responses:  map[string]*Response{},
+		},
+		names: map[reflect.Type]string{},
+	}
+
+	d.Components.Schemas[problemName] = problemSchema()
+	d.Components.Responses[problemName] = &Response{
+		Description: "an RFC 9457 problem describing why the request failed",
+		Content: map[string]MediaType{
+			"application/problem+json": {Schema: &Schema{Ref: schemaRef(problemName)}},
+		},
+	}
+
+	return d
+}
+
+// AddParameter adds a parameter to the components and returns a reference to it.
+func (d *Document) AddParameter(name string, p *Parameter) *Parameter {
+	d.Components.Parameters[name] = p
+	return ParameterRef(name)
+}
+
+// ParameterRef references a parameter of the components.
+func ParameterRef(name string) *Parameter {
+	return &Parameter{Ref: "#/components/parameters/" + name}
+}
+
+// ProblemResponse references the RFC 9457 problem response.
+func ProblemResponse() *Response {
+	return &Response{Ref: "#/components/responses/" + problemName}
+}
+
+// RequireAPIKey requires every operation to send an api key in header.
+func (d *Document) RequireAPIKey(header string) {
+	d.Components.SecuritySchemes = map[string]*SecurityScheme{
+		"apiKey": {Type: "apiKey", Name: header, In: "header"},
+	}
+	d.Security = []map[string][]string{{"apiKey": {}}}
+}
+
+// AddRoutes adds an operation for every method of every route of router,
+// described by describe with the path in OpenAPI form. Path parameters and an
+// operation id derived from the method and path are added when the
+// description has none. Routes describe does not know are an error, so that
+// the document cannot fall behind the router.
+func (d *Document) AddRoutes(router *mux.Router, describe func(method string, path string) (*Operation, bool)) error {
+	return router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		// prefixes of subrouters have no methods
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		path, variables := convertPath(template)
+
+		for _, method := range methods {
+			op, ok := describe(method, path)
+			if !ok {
+				return fmt.Errorf("route %s %s is not described", method, path)
+			}
+
+			if op.OperationID == "" {
+				op.OperationID = OperationID(method, path)
+			}
+
+			for _, v := range variables {
+				if !slices.ContainsFunc(op.Parameters, func(p *Parameter) bool { return p.In == "path" && p.Name == v.name }) {
+					op.Parameters = append(op.Parameters, v.parameter())
+				}
+			}
+
+			if _, ok := op.Responses["default"]; !ok {
+				if op.Responses == nil {
+					op.Responses = map[string]*Response{}
+				}
+				op.Responses["default"] = ProblemResponse()
+			}
+
+			item, ok := d.Paths[path]
+			if !ok {
+				item = PathItem{}
+				d.Paths[path] = item
+			}
+			item[strings.ToLower(method)] = op
+		}
+
+		return nil
+	})
+}
+
+type variable struct {
+	name    string
+	pattern string
+}
+
+func (v variable) parameter() *Parameter {
+	schema := &Schema{Type: "string"}
+	if v.pattern != "" {
+		schema.Pattern = "^(?:" + v.pattern + ")$"
+	}
+
+	return &Parameter{Name: v.name, In: "path", Required: true, Schema: schema}
+}
+
+// convertPath converts a mux path template into OpenAPI form, moving the
+// patterns of its variables out of the path, e.g. /almanac/{month:[0-9]{1,2}}
+// becomes /almanac/{month}.
+func convertPath(template string) (string, []variable) {
+	var (
+		path      strings.Builder
+		variables []variable
+	)
+
+	for i := 0; i < len(template); i++ {
+		if template[i] != '{' {
+			path.WriteByte(template[i])
+			continue
+		}
+
+		// patterns may contain braces of their own
+		depth := 0
+		end := i
+		for ; end < len(template); end++ {
+			if template[end] == '{' {
+				depth++
+			} else if template[end] == '}' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+
+		name, pattern, _ := strings.Cut(template[i+1:end], ":")
+		variables = append(variables, variable{name: name, pattern: pattern})
+		path.WriteString("{" + name + "}")
+		i = end
+	}
+
+	return path.String(), variables
+}
+
+// OperationID derives an operation id from a method and an OpenAPI path, e.g.
+// getRainDaily for GET /api/v1/rain/daily. Segments before the version are
+// left out.
+func OperationID(method string, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	for i, s := range segments {
+		if isVersion(s) {
+			segments = segments[i+1:]
+			break
+		}
+	}
+
+	for _, s := range segments {
+		for _, word := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+
+	return id.String()
+}
+
+func isVersion(segment string) bool {
+	return len(segment) > 1 && segment[0] == 'v' && strings.Trim(segment[1:], "0123456789") == ""
+}
+
+// JSON is the content of a json body with the schema of v.
+func (d *Document) JSON(v any) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: d.Schema(v)}}
+}
+
+// OK is a 200 response with content.
+func OK(description string, content map[string]MediaType) map[string]*Response {
+	return map[string]*Response{
+		fmt.Sprint(http.StatusOK): {Description: description, Content: content},
+	}
+}
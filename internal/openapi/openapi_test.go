@@ -0,0 +1,156 @@
+package openapi
+
+import (
+	"io/fs"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestConvertPath(t *testing.T) {
+	path, variables := convertPath("/api/v1/almanac/{month:[0-9]{1,2}}-{day:[0-9]{1,2}}")
+	if path != "/api/v1/almanac/{month}-{day}" {
+		t.Errorf("unexpected path %s", path)
+	}
+
+	expected := []variable{{name: "month", pattern: "[0-9]{1,2}"}, {name: "day", pattern: "[0-9]{1,2}"}}
+	if !reflect.DeepEqual(variables, expected) {
+		t.Errorf("expected variables %v, got %v", expected, variables)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/rain/daily":                      "getRainDaily",
+		"/api/v1/almanac/{month}-{day}":           "getAlmanacMonthDay",
+		"/api/v1/electricitymaps/power_breakdown": "getElectricitymapsPowerBreakdown",
+		"/api/v1/{metric}":                        "getMetric",
+	}
+
+	for path, expected := range tests {
+		if id := OperationID(http.MethodGet, path); id != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, id)
+		}
+	}
+}
+
+type inner struct {
+	Name string `json:"name"`
+}
+
+type outer struct {
+	inner
+	Time     time.Time         `json:"time"`
+	Value    *float64          `json:"value"`
+	Count    *int64            `json:"count,omitempty"`
+	Inner    *inner            `json:"inner,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Values   []float64         `json:"values"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+func TestSchema(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"})
+
+	s := d.Schema([]outer{})
+	if s.Type != "array" || s.Items.Ref != "#/components/schemas/outer" {
+		t.Fatalf("expected an array of outer references, got %+v", s)
+	}
+
+	o := d.Components.Schemas["outer"]
+	if o == nil {
+		t.Fatal("expected outer in the components")
+	}
+
+	expected := []string{"name", "time", "value", "labels", "values"}
+	if !reflect.DeepEqual(o.Required, expected) {
+		t.Errorf("expected required %v, got %v", expected, o.Required)
+	}
+
+	if len(o.Properties) != 7 {
+		t.Errorf("expected 7 properties, got %d", len(o.Properties))
+	}
+
+	if o.Properties["time"].Format != "date-time" {
+		t.Errorf("expected time to be a date-time, got %+v", o.Properties["time"])
+	}
+
+	if !reflect.DeepEqual(o.Properties["value"].Type, []string{"number", "null"}) {
+		t.Errorf("expected value to be a nullable number, got %+v", o.Properties["value"])
+	}
+
+	nested := o.Properties["inner"]
+	if len(nested.OneOf) != 2 || nested.OneOf[0].Ref != "#/components/schemas/inner" {
+		t.Errorf("expected inner to be a nullable reference, got %+v", nested)
+	}
+
+	if o.Properties["labels"].AdditionalProperties.Type != "string" {
+		t.Errorf("expected labels to be a map of strings, got %+v", o.Properties["labels"])
+	}
+}
+
+func TestAddRoutes(t *testing.T) {
+	r := mux.NewRouter()
+	v1 := r.PathPrefix("/api/v1").Subrouter()
+	v1.HandleFunc("/export/{table}", nil).Methods(http.MethodGet)
+	v1.HandleFunc("/graphql", nil).Methods(http.MethodGet, http.MethodPost)
+
+	d := New(Info{Title: "test", Version: "1"})
+	err := d.AddRoutes(r, func(method string, path string) (*Operation, bool) {
+		return &Operation{}, true
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	export := d.Paths["/api/v1/export/{table}"]["get"]
+	if export == nil {
+		t.Fatalf("expected the export route, got paths %v", d.Paths)
+	}
+
+	if export.OperationID != "getExportTable" {
+		t.Errorf("expected operation id getExportTable, got %s", export.OperationID)
+	}
+
+	if len(export.Parameters) != 1 || export.Parameters[0].Name != "table" || export.Parameters[0].In != "path" {
+		t.Errorf("expected the table path parameter, got %+v", export.Parameters)
+	}
+
+	if export.Responses["default"] == nil {
+		t.Error("expected a default problem response")
+	}
+
+	if len(d.Paths["/api/v1/graphql"]) != 2 {
+		t.Errorf("expected get and post graphql, got %v", d.Paths["/api/v1/graphql"])
+	}
+
+	err = d.AddRoutes(r, func(method string, path string) (*Operation, bool) {
+		return &Operation{}, path != "/api/v1/graphql"
+	})
+	if err == nil {
+		t.Error("expected an error for undescribed routes")
+	}
+}
+
+func TestDocsAssets(t *testing.T) {
+	page := string(DocsPage)
+	if strings.Contains(page, "://") {
+		t.Error("expected the docs page to load nothing from other origins")
+	}
+
+	for _, name := range []string{"docs.js", "swagger-ui-bundle.js", "swagger-ui.css"} {
+		if !strings.Contains(page, "docs/"+name) {
+			t.Errorf("expected the docs page to load %s", name)
+		}
+
+		_, err := fs.Stat(DocsAssets, name)
+		if err != nil {
+			t.Errorf("expected %s to be embedded: %s", name, err)
+		}
+	}
+}
@@ -0,0 +1,459 @@
+// Package rpc serves the grpc api described by proto/lfpweather/v1, reading
+// the same data as the http handlers.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/derived"
+	"github.com/michaelpeterswa/lfpweather-api/internal/latest"
+	"github.com/michaelpeterswa/lfpweather-api/internal/stream"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+	lfpweatherv1 "github.com/michaelpeterswa/lfpweather-api/pkg/proto/lfpweather/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultBirdLookback is the lookback of GetBirdCounts when none is given.
+const defaultBirdLookback = 24 * time.Hour
+
+type WeatherServer struct {
+	lfpweatherv1.UnimplementedWeatherServiceServer
+
+	timescaleClient *timescale.TimescaleClient
+	derivedClient   *derived.DerivedClient
+	hub             *stream.Hub
+	devices         catalog.Devices
+	location        *time.Location
+}
+
+func NewWeatherServer(timescaleClient *timescale.TimescaleClient, derivedClient *derived.DerivedClient, hub *stream.Hub, devices catalog.Devices, location *time.Location) *WeatherServer {
+	return &WeatherServer{
+		timescaleClient: timescaleClient,
+		derivedClient:   derivedClient,
+		hub:             hub,
+		devices:         devices,
+		location:        location,
+	}
+}
+
+// unitSystem converts a requested unit system, defaulting to imperial.
+func unitSystem(system lfpweatherv1.UnitSystem) (units.System, error) {
+	switch system {
+	case lfpweatherv1.UnitSystem_UNIT_SYSTEM_UNSPECIFIED, lfpweatherv1.UnitSystem_UNIT_SYSTEM_IMPERIAL:
+		return units.Imperial, nil
+	case lfpweatherv1.UnitSystem_UNIT_SYSTEM_METRIC:
+		return units.Metric, nil
+	case lfpweatherv1.UnitSystem_UNIT_SYSTEM_SI:
+		return units.SI, nil
+	default:
+		return "", status.Errorf(codes.InvalidArgument, "%s is not a known unit system", system)
+	}
+}
+
+// latestMetrics looks up metrics with a latest value, nil selects all of them.
+func latestMetrics(names []string) ([]string, error) {
+	for _, name := range names {
+		metric, ok := catalog.Lookup(name)
+		if !ok || !metric.HasLast() {
+			return nil, status.Errorf(codes.InvalidArgument, "%s is not a metric with a latest value", name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	return names, nil
+}
+
+// lookupDevice resolves a device selector, the zero device when it is empty.
+func (s *WeatherServer) lookupDevice(selector string) (catalog.Device, error) {
+	if selector == "" {
+		return catalog.Device{}, nil
+	}
+
+	device, ok := s.devices.Lookup(selector)
+	if !ok {
+		return catalog.Device{}, status.Errorf(codes.NotFound, "%s is not a known device", selector)
+	}
+
+	return device, nil
+}
+
+// serialNumber resolves a device selector for a metric, defaulting to the
+// first device for per device metrics.
+func (s *WeatherServer) serialNumber(metric catalog.Metric, selector string) (string, error) {
+	if !metric.PerDevice {
+		if selector != "" {
+			return "", status.Errorf(codes.InvalidArgument, "%s is not reported per device", metric.Name)
+		}
+		return "", nil
+	}
+
+	if selector == "" {
+		return s.devices.Default().SerialNumber, nil
+	}
+
+	device, err := s.lookupDevice(selector)
+	if err != nil {
+		return "", err
+	}
+
+	return device.SerialNumber, nil
+}
+
+// newReading converts a latest value of metric into system.
+func newReading(metric catalog.Metric, device string, t time.Time, value float64, system units.System, now time.Time) *lfpweatherv1.Reading {
+	_, stale := metric.Age(t, now)
+
+	return &lfpweatherv1.Reading{
+		Metric: metric.Name,
+		Device: device,
+		Time:   timestamppb.New(t),
+		Value:  metric.Quantity.Convert(system, value),
+		Unit:   metric.UnitIn(system),
+		Stale:  stale,
+	}
+}
+
+// internalError logs an error reading the database and hides it from clients.
+func internalError(msg string, err error) error {
+	slog.Error(msg, slog.String("error", err.Error()))
+	return status.Error(codes.Internal, msg)
+}
+
+// GetLatest reads the latest row of every table once, like /current, for
+// every device unless the request selects one.
+func (s *WeatherServer) GetLatest(ctx context.Context, req *lfpweatherv1.GetLatestRequest) (*lfpweatherv1.GetLatestResponse, error) {
+	system, err := unitSystem(req.GetUnits())
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := latestMetrics(req.GetMetrics())
+	if err != nil {
+		return nil, err
+	}
+
+	devices := s.devices
+	if req.GetDevice() != "" {
+		device, err := s.lookupDevice(req.GetDevice())
+		if err != nil {
+			return nil, err
+		}
+		devices = catalog.Devices{device}
+	}
+
+	groups, err := latest.Groups(devices...)
+	if err != nil {
+		return nil, internalError("failed to group latest metrics", err)
+	}
+
+	latest.Fetch(ctx, s.timescaleClient, groups)
+
+	now := time.Now()
+	res := &lfpweatherv1.GetLatestResponse{}
+
+	for _, g := range groups {
+		if g.Err != nil {
+			return nil, internalError(fmt.Sprintf("failed to get latest %s data", g.Table), g.Err)
+		}
+
+		// the table has no readings yet, e.g. from a new or offline device
+		if g.Row == nil {
+			continue
+		}
+
+		for _, metric := range g.Metrics {
+			if metrics != nil && !slices.Contains(metrics, metric.Name) {
+				continue
+			}
+
+			// the latest row of the table has no reading of the metric
+			value := g.Value(metric)
+			if value == nil {
+				continue
+			}
+
+			res.Readings = append(res.Readings, newReading(metric, g.Device, g.Row.Time, *value, system, now))
+		}
+	}
+
+	return res, nil
+}
+
+// getColumn reads a metric from its column or, for derived metrics, computes it.
+func (s *WeatherServer) getColumn(ctx context.Context, metric catalog.Metric, tp timescale.GetColumnTemplateParameters) ([]timescale.GetColumnResponse, error) {
+	if !metric.Derived {
+		return s.timescaleClient.GetColumn(ctx, tp)
+	}
+
+	derivation, ok := derived.Lookup(metric.Name)
+	if !ok {
+		return nil, fmt.Errorf("no derivation for %s", metric.Name)
+	}
+
+	return s.derivedClient.GetColumn(ctx, derivation, tp)
+}
+
+// seriesRange resolves the range and bucket of a series request with the same
+// defaults and bounds as ?start=, ?end= and ?bucket=.
+func seriesRange(req *lfpweatherv1.GetSeriesRequest, now time.Time) (timerange.Range, time.Duration, error) {
+	var start, end, bucket string
+	if req.GetStart() != nil {
+		start = req.GetStart().AsTime().Format(time.RFC3339Nano)
+	}
+	if req.GetEnd() != nil {
+		end = req.GetEnd().AsTime().Format(time.RFC3339Nano)
+	}
+	if req.GetBucket() != nil {
+		bucket = req.GetBucket().AsDuration().String()
+	}
+
+	tr, err := timerange.Parse(start, end, now)
+	if err != nil {
+		return timerange.Range{}, 0, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	b, err := timerange.ParseBucket(bucket, tr)
+	if err != nil {
+		return timerange.Range{}, 0, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return tr, b, nil
+}
+
+// GetSeries reads a metric bucketed over a range, like /{metric}.
+func (s *WeatherServer) GetSeries(ctx context.Context, req *lfpweatherv1.GetSeriesRequest) (*lfpweatherv1.GetSeriesResponse, error) {
+	metric, ok := catalog.Lookup(req.GetMetric())
+	if !ok || !metric.HasRange() {
+		return nil, status.Errorf(codes.NotFound, "%s is not a known metric", req.GetMetric())
+	}
+
+	system, err := unitSystem(req.GetUnits())
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := s.serialNumber(metric, req.GetDevice())
+	if err != nil {
+		return nil, err
+	}
+
+	tr, bucket, err := seriesRange(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	location := s.location
+	if req.GetTimeZone() != "" {
+		// Local names the server's zone rather than one the database knows
+		location, err = time.LoadLocation(req.GetTimeZone())
+		if err != nil || req.GetTimeZone() == "Local" {
+			return nil, status.Errorf(codes.InvalidArgument, "%s is not an iana time zone", req.GetTimeZone())
+		}
+	}
+
+	tp := timescale.GetColumnTemplateParameters{
+		ColumnName:   metric.Column,
+		TimeBucket:   timerange.FormatInterval(bucket),
+		Start:        tr.Start,
+		End:          tr.End,
+		TableName:    metric.Table,
+		SerialNumber: serialNumber,
+		Location:     location,
+		Relative:     tr.Relative,
+	}
+
+	if metric.Circular {
+		directions, err := s.timescaleClient.GetWindDirection(ctx, tp)
+		if err != nil {
+			return nil, internalError(fmt.Sprintf("failed to get %s data", metric.Name), err)
+		}
+
+		return &lfpweatherv1.GetSeriesResponse{
+			Metric:  metric.Name,
+			Unit:    metric.UnitIn(system),
+			Buckets: directionBuckets(directions),
+		}, nil
+	}
+
+	values, err := s.getColumn(ctx, metric, tp)
+	if err != nil {
+		return nil, internalError(fmt.Sprintf("failed to get %s data", metric.Name), err)
+	}
+
+	convert := func(v *float64) *float64 {
+		if v == nil {
+			return nil
+		}
+
+		c := metric.Quantity.Convert(system, *v)
+		return &c
+	}
+
+	res := &lfpweatherv1.GetSeriesResponse{
+		Metric:  metric.Name,
+		Unit:    metric.UnitIn(system),
+		Buckets: make([]*lfpweatherv1.Bucket, 0, len(values)),
+	}
+
+	for _, v := range values {
+		res.Buckets = append(res.Buckets, &lfpweatherv1.Bucket{
+			Time: timestamppb.New(v.Time),
+			Avg:  convert(v.Avg),
+			Min:  convert(v.Min),
+			Max:  convert(v.Max),
+		})
+	}
+
+	return res, nil
+}
+
+// directionBuckets converts vector averaged directions to buckets. A plain
+// min or max of angles is meaningless, so only avg is set.
+func directionBuckets(directions []timescale.GetWindDirectionResponse) []*lfpweatherv1.Bucket {
+	buckets := make([]*lfpweatherv1.Bucket, 0, len(directions))
+	for _, d := range directions {
+		buckets = append(buckets, &lfpweatherv1.Bucket{
+			Time: timestamppb.New(d.Time),
+			Avg:  d.Direction,
+		})
+	}
+
+	return buckets
+}
+
+// StreamLatest sends the latest value of every selected metric, or the
+// readings missed since last_event_id, then every new reading. A client that
+// falls behind resumes after the last reading it was sent.
+func (s *WeatherServer) StreamLatest(req *lfpweatherv1.StreamLatestRequest, srv lfpweatherv1.WeatherService_StreamLatestServer) error {
+	system, err := unitSystem(req.GetUnits())
+	if err != nil {
+		return err
+	}
+
+	metrics, err := latestMetrics(req.GetMetrics())
+	if err != nil {
+		return err
+	}
+
+	device, err := s.lookupDevice(req.GetDevice())
+	if err != nil {
+		return err
+	}
+
+	filter := func(reading stream.Reading) bool {
+		if metrics != nil && !slices.Contains(metrics, reading.Metric) {
+			return false
+		}
+
+		return device.Name == "" || reading.Device == "" || reading.Device == device.Name
+	}
+
+	lastEventID := req.GetLastEventId()
+
+	subscription, missed := s.hub.Subscribe(filter, lastEventID)
+	defer func() {
+		s.hub.Unsubscribe(subscription)
+	}()
+
+	send := func(events []stream.Event) error {
+		now := time.Now()
+
+		for _, e := range events {
+			metric, ok := catalog.Lookup(e.Metric)
+			if !ok {
+				continue
+			}
+
+			err := srv.Send(&lfpweatherv1.StreamLatestResponse{
+				Id:      e.ID(),
+				Reading: newReading(metric, e.Device, e.Time, e.Value, system, now),
+			})
+			if err != nil {
+				return err
+			}
+
+			lastEventID = e.ID()
+		}
+
+		return nil
+	}
+
+	err = send(missed)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-srv.Context().Done():
+			return nil
+		case e, ok := <-subscription.C:
+			if !ok {
+				subscription, missed = s.hub.Subscribe(filter, lastEventID)
+				err = send(missed)
+			} else {
+				err = send([]stream.Event{e})
+			}
+
+			if err != nil {
+				if errors.Is(srv.Context().Err(), context.Canceled) {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+}
+
+// GetBirdCounts reads the species detected over a lookback, like /birdnet/24h.
+func (s *WeatherServer) GetBirdCounts(ctx context.Context, req *lfpweatherv1.GetBirdCountsRequest) (*lfpweatherv1.GetBirdCountsResponse, error) {
+	lookback := defaultBirdLookback
+	if req.GetLookback() != nil {
+		lookback = req.GetLookback().AsDuration()
+	}
+
+	if lookback < time.Second || lookback > timescale.MaxBirdnetLookback {
+		return nil, status.Errorf(codes.InvalidArgument, "lookback %s must be between 1s and %s", lookback, timerange.FormatInterval(timescale.MaxBirdnetLookback))
+	}
+
+	if req.GetLimit() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "limit %d must not be negative", req.GetLimit())
+	}
+
+	birds, err := s.timescaleClient.GetBirdnet(ctx, timescale.GetBirdnetTemplateParameters{
+		LookbackInterval: timerange.FormatInterval(lookback.Truncate(time.Second)),
+	})
+	if err != nil {
+		return nil, internalError("failed to get bird data", err)
+	}
+
+	if req.GetLimit() > 0 {
+		birds = birds[:min(int(req.GetLimit()), len(birds))]
+	}
+
+	res := &lfpweatherv1.GetBirdCountsResponse{
+		Birds: make([]*lfpweatherv1.BirdCount, 0, len(birds)),
+	}
+
+	for _, bird := range birds {
+		res.Birds = append(res.Birds, &lfpweatherv1.BirdCount{
+			CommonName: bird.CommonName,
+			Count:      int64(bird.Count),
+		})
+	}
+
+	return res, nil
+}
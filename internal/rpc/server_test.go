@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+	lfpweatherv1 "github.com/michaelpeterswa/lfpweather-api/pkg/proto/lfpweather/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSeriesRange(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tr, bucket, err := seriesRange(&lfpweatherv1.GetSeriesRequest{}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !tr.End.Equal(now) || !tr.Start.Equal(now.Add(-24*time.Hour)) {
+		t.Errorf("expected the last 24h, got %s to %s", tr.Start, tr.End)
+	}
+	if bucket <= 0 {
+		t.Errorf("expected an automatic bucket, got %s", bucket)
+	}
+
+	start := now.Add(-90 * time.Minute).Add(500 * time.Millisecond)
+	tr, bucket, err = seriesRange(&lfpweatherv1.GetSeriesRequest{
+		Start:  timestamppb.New(start),
+		End:    timestamppb.New(now),
+		Bucket: durationpb.New(15 * time.Minute),
+	}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !tr.Start.Equal(start) {
+		t.Errorf("expected start %s, got %s", start, tr.Start)
+	}
+	if bucket != 15*time.Minute {
+		t.Errorf("expected a 15m bucket, got %s", bucket)
+	}
+
+	for name, req := range map[string]*lfpweatherv1.GetSeriesRequest{
+		"reversed": {Start: timestamppb.New(now), End: timestamppb.New(now.Add(-time.Hour))},
+		"tiny":     {Bucket: durationpb.New(time.Second)},
+	} {
+		_, _, err := seriesRange(req, now)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: expected invalid argument, got %v", name, err)
+		}
+	}
+}
+
+func TestValidation(t *testing.T) {
+	devices, err := catalog.ParseDevices([]string{"outdoor:84fce6070dd4"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// every request is rejected before the database is read
+	s := NewWeatherServer(nil, nil, nil, devices, time.UTC)
+	ctx := context.Background()
+
+	_, err = s.GetLatest(ctx, &lfpweatherv1.GetLatestRequest{Metrics: []string{"nope"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown metric: expected invalid argument, got %v", err)
+	}
+
+	_, err = s.GetLatest(ctx, &lfpweatherv1.GetLatestRequest{Device: "indoor"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown device: expected not found, got %v", err)
+	}
+
+	_, err = s.GetLatest(ctx, &lfpweatherv1.GetLatestRequest{Units: lfpweatherv1.UnitSystem(42)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown units: expected invalid argument, got %v", err)
+	}
+
+	_, err = s.GetSeries(ctx, &lfpweatherv1.GetSeriesRequest{Metric: "nope"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("unknown series: expected not found, got %v", err)
+	}
+
+	_, err = s.GetSeries(ctx, &lfpweatherv1.GetSeriesRequest{Metric: "temperature", Device: "outdoor"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("station metric with device: expected invalid argument, got %v", err)
+	}
+
+	_, err = s.GetSeries(ctx, &lfpweatherv1.GetSeriesRequest{Metric: "temperature", TimeZone: "Local"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("local time zone: expected invalid argument, got %v", err)
+	}
+
+	_, err = s.GetBirdCounts(ctx, &lfpweatherv1.GetBirdCountsRequest{Limit: -1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("negative limit: expected invalid argument, got %v", err)
+	}
+
+	_, err = s.GetBirdCounts(ctx, &lfpweatherv1.GetBirdCountsRequest{Lookback: durationpb.New(400 * 24 * time.Hour)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("long lookback: expected invalid argument, got %v", err)
+	}
+}
+
+func TestDirectionBuckets(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// the direction comes from the vector average, 0 for readings of 350 and 10
+	direction, speed, steadiness := 0.0, 5.0, 0.98
+	buckets := directionBuckets([]timescale.GetWindDirectionResponse{
+		{Time: now, Direction: &direction, Speed: &speed, Steadiness: &steadiness},
+		{Time: now.Add(time.Hour)},
+	})
+	if len(buckets) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(buckets))
+	}
+	if buckets[0].Avg == nil || *buckets[0].Avg != 0 {
+		t.Errorf("expected the vector averaged direction, got %v", buckets[0].Avg)
+	}
+	if buckets[0].Min != nil || buckets[0].Max != nil {
+		t.Errorf("expected min and max of a circular metric to be unset, got %v and %v", buckets[0].Min, buckets[0].Max)
+	}
+	if !buckets[0].GetTime().AsTime().Equal(now) {
+		t.Errorf("expected time %s, got %s", now, buckets[0].GetTime().AsTime())
+	}
+	if buckets[1].Avg != nil {
+		t.Errorf("expected an empty bucket to have no direction, got %v", *buckets[1].Avg)
+	}
+}
@@ -0,0 +1,348 @@
+// Package stream fans new readings out to live subscribers. A single Hub
+// watches the database, by polling and optionally by LISTEN/NOTIFY, however
+// many clients are connected.
+package stream
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/catalog"
+	"github.com/michaelpeterswa/lfpweather-api/internal/latest"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+	defaultHistory      = 1024
+	defaultBuffer       = 64
+	// listenRetry is how long to wait before listening again after the
+	// listening connection failed.
+	listenRetry = 30 * time.Second
+)
+
+// Reading is a new latest value of a metric, in the units the station reports.
+type Reading struct {
+	Metric string
+	Device string
+	Time   time.Time
+	Value  float64
+}
+
+// Event is a reading with its position in the hub's history.
+type Event struct {
+	Reading
+	epoch int64
+	seq   uint64
+}
+
+// ID identifies the event to resume after, e.g. with Last-Event-ID.
+func (e Event) ID() string {
+	return fmt.Sprintf("%d-%d", e.epoch, e.seq)
+}
+
+// Filter selects the readings a subscriber receives, nil selects all.
+type Filter func(Reading) bool
+
+// Subscription receives events on C until it is unsubscribed, or until it
+// falls more than the buffer behind, when C is closed so the client can
+// reconnect and resume.
+type Subscription struct {
+	C <-chan Event
+
+	c      chan Event
+	filter Filter
+}
+
+type HubOption func(*Hub)
+
+// WithPollInterval sets how often the latest rows are read.
+func WithPollInterval(d time.Duration) HubOption {
+	return func(h *Hub) {
+		h.pollInterval = d
+	}
+}
+
+// WithListen also reads the latest rows as soon as the database notifies
+// timescale.ReadingsChannel.
+func WithListen(listen bool) HubOption {
+	return func(h *Hub) {
+		h.listen = listen
+	}
+}
+
+// WithHistory sets how many events are kept for resuming subscribers.
+func WithHistory(n int) HubOption {
+	return func(h *Hub) {
+		h.historySize = n
+	}
+}
+
+// WithBuffer sets how many events a subscriber may fall behind.
+func WithBuffer(n int) HubOption {
+	return func(h *Hub) {
+		h.buffer = n
+	}
+}
+
+type Hub struct {
+	timescaleClient *timescale.TimescaleClient
+	devices         catalog.Devices
+	pollInterval    time.Duration
+	listen          bool
+	historySize     int
+	buffer          int
+
+	// epoch tells events of this process from those of earlier ones
+	epoch int64
+
+	mu          sync.Mutex
+	seq         uint64
+	history     []Event
+	latest      map[string]Event
+	subscribers map[*Subscription]struct{}
+}
+
+func NewHub(timescaleClient *timescale.TimescaleClient, devices catalog.Devices, opts ...HubOption) *Hub {
+	h := &Hub{
+		timescaleClient: timescaleClient,
+		devices:         devices,
+		pollInterval:    defaultPollInterval,
+		historySize:     defaultHistory,
+		buffer:          defaultBuffer,
+		epoch:           time.Now().Unix(),
+		latest:          map[string]Event{},
+		subscribers:     map[*Subscription]struct{}{},
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+func readingKey(r Reading) string {
+	return r.Metric + "/" + r.Device
+}
+
+// Run watches the database until ctx is done.
+func (h *Hub) Run(ctx context.Context) {
+	groups, err := latest.Groups(h.devices...)
+	if err != nil {
+		slog.Error("failed to group metrics", slog.String("error", err.Error()))
+		return
+	}
+
+	wake := make(chan string, 1)
+	if h.listen {
+		go h.listenLoop(ctx, wake)
+	}
+
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	h.poll(ctx, groups, "")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.poll(ctx, groups, "")
+		case table := <-wake:
+			h.poll(ctx, groups, table)
+		}
+	}
+}
+
+// listenLoop wakes the hub whenever a table is notified, listening again
+// after a while when the connection fails.
+func (h *Hub) listenLoop(ctx context.Context, wake chan<- string) {
+	for {
+		err := h.timescaleClient.Listen(ctx, timescale.ReadingsChannel, func(table string) {
+			select {
+			case wake <- table:
+			default:
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Error("failed to listen for readings, polling until listening again", slog.String("error", err.Error()))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetry):
+		}
+	}
+}
+
+// poll reads the latest row of the groups of table, or of every group when
+// table is empty, and publishes the readings newer than those seen before.
+func (h *Hub) poll(ctx context.Context, groups []*latest.Group, table string) {
+	var polled []*latest.Group
+	for _, g := range groups {
+		if table == "" || g.Table == table {
+			polled = append(polled, g)
+		}
+	}
+
+	latest.Fetch(ctx, h.timescaleClient, polled)
+
+	var readings []Reading
+	for _, g := range polled {
+		if g.Err != nil {
+			slog.Error("failed to poll readings", slog.String("table", g.Table), slog.String("error", g.Err.Error()))
+			continue
+		}
+
+		for _, metric := range g.Metrics {
+			value := g.Value(metric)
+			if value == nil {
+				continue
+			}
+
+			readings = append(readings, Reading{
+				Metric: metric.Name,
+				Device: g.Device,
+				Time:   g.Row.Time,
+				Value:  *value,
+			})
+		}
+	}
+
+	h.publish(readings)
+}
+
+// publish records the readings that are newer than the latest of their metric
+// and sends them to the subscribers they match.
+func (h *Hub) publish(readings []Reading) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, r := range readings {
+		key := readingKey(r)
+		if previous, ok := h.latest[key]; ok && !r.Time.After(previous.Time) {
+			continue
+		}
+
+		h.seq++
+		e := Event{Reading: r, epoch: h.epoch, seq: h.seq}
+
+		h.latest[key] = e
+		h.history = append(h.history, e)
+		if len(h.history) > h.historySize {
+			h.history = h.history[len(h.history)-h.historySize:]
+		}
+
+		for s := range h.subscribers {
+			if s.filter != nil && !s.filter(r) {
+				continue
+			}
+
+			select {
+			case s.c <- e:
+			default:
+				// the subscriber fell behind, it resumes when it reconnects
+				h.remove(s)
+			}
+		}
+	}
+}
+
+// Subscribe registers a subscriber and returns the events it missed. After a
+// lastEventID of this process it is the events since, otherwise the latest
+// event of every metric.
+func (h *Hub) Subscribe(filter Filter, lastEventID string) (*Subscription, []Event) {
+	c := make(chan Event, h.buffer)
+	s := &Subscription{C: c, c: c, filter: filter}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.subscribers[s] = struct{}{}
+
+	var missed []Event
+	if seq, ok := h.resumeFrom(lastEventID); ok {
+		for _, e := range h.history {
+			if e.seq > seq && (filter == nil || filter(e.Reading)) {
+				missed = append(missed, e)
+			}
+		}
+		return s, missed
+	}
+
+	return s, h.latestEvents(filter)
+}
+
+// resumeFrom parses a last event id, reporting whether the history still
+// holds every event after it.
+func (h *Hub) resumeFrom(lastEventID string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(lastEventID, "-")
+	if !ok || epoch != strconv.FormatInt(h.epoch, 10) {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil || n > h.seq {
+		return 0, false
+	}
+
+	if len(h.history) > 0 && n+1 < h.history[0].seq {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// Unsubscribe removes a subscriber and closes its channel.
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(s)
+}
+
+func (h *Hub) remove(s *Subscription) {
+	if _, ok := h.subscribers[s]; !ok {
+		return
+	}
+
+	delete(h.subscribers, s)
+	close(s.c)
+}
+
+// Latest returns the latest event of every metric matching filter.
+func (h *Hub) Latest(filter Filter) []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.latestEvents(filter)
+}
+
+// latestEvents returns the latest event of every metric matching filter in
+// the order they happened. h.mu must be held.
+func (h *Hub) latestEvents(filter Filter) []Event {
+	var events []Event
+	for _, e := range h.latest {
+		if filter == nil || filter(e.Reading) {
+			events = append(events, e)
+		}
+	}
+
+	slices.SortFunc(events, func(a, b Event) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	return events
+}
@@ -0,0 +1,99 @@
+package stream
+
+import (
+	"testing"
+	"time"
+)
+
+func readingAt(metric string, minute int, value float64) Reading {
+	return Reading{
+		Metric: metric,
+		Time:   time.Date(2024, 6, 1, 12, minute, 0, 0, time.UTC),
+		Value:  value,
+	}
+}
+
+func TestPublish(t *testing.T) {
+	h := NewHub(nil, nil)
+
+	s, missed := h.Subscribe(func(r Reading) bool { return r.Metric == "temperature" }, "")
+	if len(missed) != 0 {
+		t.Fatalf("expected nothing missed, got %d events", len(missed))
+	}
+
+	h.publish([]Reading{readingAt("temperature", 0, 70), readingAt("humidity", 0, 40)})
+	// an unchanged reading is not an update
+	h.publish([]Reading{readingAt("temperature", 0, 70), readingAt("temperature", 1, 71)})
+
+	for _, expected := range []float64{70, 71} {
+		select {
+		case e := <-s.C:
+			if e.Metric != "temperature" || e.Value != expected {
+				t.Errorf("expected temperature %v, got %s %v", expected, e.Metric, e.Value)
+			}
+		default:
+			t.Fatalf("expected temperature %v, got nothing", expected)
+		}
+	}
+
+	select {
+	case e := <-s.C:
+		t.Errorf("expected no more events, got %s %v", e.Metric, e.Value)
+	default:
+	}
+}
+
+func TestSubscribeResume(t *testing.T) {
+	h := NewHub(nil, nil, WithHistory(3))
+
+	var events []Event
+	for minute := range 5 {
+		h.publish([]Reading{readingAt("temperature", minute, float64(minute))})
+		events = append(events, h.Latest(nil)...)
+	}
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		expected    []float64
+	}{
+		{name: "New subscriber", expected: []float64{4}},
+		{name: "Within history", lastEventID: events[2].ID(), expected: []float64{3, 4}},
+		{name: "Up to date", lastEventID: events[4].ID()},
+		{name: "Before history", lastEventID: events[0].ID(), expected: []float64{4}},
+		{name: "Earlier process", lastEventID: "1-3", expected: []float64{4}},
+		{name: "Malformed", lastEventID: "latest", expected: []float64{4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, missed := h.Subscribe(nil, tt.lastEventID)
+			defer h.Unsubscribe(s)
+
+			if len(missed) != len(tt.expected) {
+				t.Fatalf("expected %d events, got %d", len(tt.expected), len(missed))
+			}
+			for i, e := range missed {
+				if e.Value != tt.expected[i] {
+					t.Errorf("expected %v, got %v", tt.expected[i], e.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	h := NewHub(nil, nil, WithBuffer(1))
+
+	s, _ := h.Subscribe(nil, "")
+
+	h.publish([]Reading{readingAt("temperature", 0, 70), readingAt("humidity", 0, 40)})
+
+	<-s.C
+	if _, ok := <-s.C; ok {
+		t.Fatal("expected the channel of a subscriber that fell behind to be closed")
+	}
+
+	// unsubscribing a removed subscriber is harmless
+	h.Unsubscribe(s)
+}
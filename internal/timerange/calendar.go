@@ -0,0 +1,76 @@
+package timerange
+
+import "time"
+
+// WaterYearStartMonth is the first month of a water year, which runs from
+// October 1 to September 30 so a wet season is not split across two years.
+const WaterYearStartMonth = time.October
+
+// StartOfDay returns local midnight of the day t falls on in loc.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// StartOfMonth returns local midnight of the first day of the month t falls on
+// in loc.
+func StartOfMonth(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+}
+
+// StartOfYear returns local midnight of January 1 of the year t falls on in
+// loc.
+func StartOfYear(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, loc)
+}
+
+// StartOfWaterYear returns local midnight of October 1 of the water year t
+// falls on in loc.
+func StartOfWaterYear(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+
+	year := t.Year()
+	if t.Month() < WaterYearStartMonth {
+		year--
+	}
+
+	return time.Date(year, WaterYearStartMonth, 1, 0, 0, 0, 0, loc)
+}
+
+// bucketOrigin is the wall clock time buckets are counted from, the default
+// origin of time_bucket, a Monday so weekly buckets start on Mondays.
+var bucketOrigin = time.Date(2000, time.January, 3, 0, 0, 0, 0, time.UTC)
+
+// TruncateIn rounds t down to a multiple of bucket from bucketOrigin on the
+// wall clock of loc, like time_bucket with a timezone, so daily buckets start
+// at local midnight even on the 23 and 25 hour days of daylight saving
+// changes, and buckets that do not divide a day start where sql's do.
+func TruncateIn(t time.Time, bucket time.Duration, loc *time.Location) time.Time {
+	since := wallClock(t, loc).Sub(bucketOrigin)
+
+	offset := since % bucket
+	if offset < 0 {
+		offset += bucket
+	}
+
+	return fromWallClock(bucketOrigin.Add(since-offset), loc)
+}
+
+// NextBucketIn returns the start of the bucket following the one starting at
+// t on the wall clock of loc.
+func NextBucketIn(t time.Time, bucket time.Duration, loc *time.Location) time.Time {
+	return fromWallClock(wallClock(t, loc).Add(bucket), loc)
+}
+
+// wallClock returns the wall clock time of t in loc as a utc time, which has
+// no daylight saving changes to step over.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func fromWallClock(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
@@ -0,0 +1,140 @@
+package timerange_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+)
+
+func TestStartOfWaterYear(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		expected time.Time
+	}{
+		{name: "Winter", t: time.Date(2025, time.February, 10, 12, 0, 0, 0, loc), expected: time.Date(2024, time.October, 1, 0, 0, 0, 0, loc)},
+		{name: "First day", t: time.Date(2025, time.October, 1, 0, 0, 0, 0, loc), expected: time.Date(2025, time.October, 1, 0, 0, 0, 0, loc)},
+		{name: "Last day", t: time.Date(2025, time.September, 30, 23, 59, 0, 0, loc), expected: time.Date(2024, time.October, 1, 0, 0, 0, 0, loc)},
+		// 2025-10-01T05:00Z is still September 30 in Pacific time
+		{name: "UTC input", t: time.Date(2025, time.October, 1, 5, 0, 0, 0, time.UTC), expected: time.Date(2024, time.October, 1, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := timerange.StartOfWaterYear(tt.t, loc)
+			if !got.Equal(tt.expected) {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStartOfDayAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	// the day clocks spring forward is only 23 hours long
+	got := timerange.StartOfDay(time.Date(2025, time.March, 9, 18, 0, 0, 0, loc), loc)
+	expected := time.Date(2025, time.March, 9, 0, 0, 0, 0, loc)
+	if !got.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	if _, offset := got.Zone(); offset != -8*60*60 {
+		t.Errorf("expected a PST offset, got %d", offset)
+	}
+}
+
+func TestBucketsInAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	day := 24 * time.Hour
+
+	got := timerange.TruncateIn(time.Date(2025, time.March, 9, 7, 30, 0, 0, time.UTC), day, loc)
+	expected := time.Date(2025, time.March, 8, 0, 0, 0, 0, loc)
+	if !got.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	// the buckets either side of the change are 23 and 25 hours long
+	expectedDays := []time.Time{
+		time.Date(2025, time.March, 9, 0, 0, 0, 0, loc),
+		time.Date(2025, time.March, 10, 0, 0, 0, 0, loc),
+	}
+	for _, e := range expectedDays {
+		got = timerange.NextBucketIn(got, day, loc)
+		if !got.Equal(e) {
+			t.Errorf("expected %s, got %s", e, got)
+		}
+	}
+
+	if length := expectedDays[1].Sub(expectedDays[0]); length != 23*time.Hour {
+		t.Errorf("expected a 23 hour day, got %s", length)
+	}
+
+	got = timerange.NextBucketIn(time.Date(2025, time.November, 2, 0, 0, 0, 0, loc), day, loc)
+	if length := got.Sub(time.Date(2025, time.November, 2, 0, 0, 0, 0, loc)); length != 25*time.Hour {
+		t.Errorf("expected a 25 hour day, got %s", length)
+	}
+}
+
+func TestTruncateInOrigin(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	// buckets are counted from 2000-01-03 like time_bucket, not from Go's
+	// zero time
+	tests := []struct {
+		name     string
+		t        time.Time
+		bucket   time.Duration
+		expected time.Time
+	}{
+		{
+			name:     "Seven hours",
+			t:        time.Date(2025, time.March, 1, 12, 0, 0, 0, loc),
+			bucket:   7 * time.Hour,
+			expected: time.Date(2025, time.March, 1, 6, 0, 0, 0, loc),
+		},
+		{
+			name:     "Five days",
+			t:        time.Date(2025, time.March, 1, 12, 0, 0, 0, loc),
+			bucket:   5 * 24 * time.Hour,
+			expected: time.Date(2025, time.February, 25, 0, 0, 0, 0, loc),
+		},
+		{
+			name:     "Week starts on Monday",
+			t:        time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC),
+			bucket:   7 * 24 * time.Hour,
+			expected: time.Date(2025, time.February, 24, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Before the origin",
+			t:        time.Date(1999, time.December, 31, 12, 0, 0, 0, time.UTC),
+			bucket:   7 * 24 * time.Hour,
+			expected: time.Date(1999, time.December, 27, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := timerange.TruncateIn(tt.t, tt.bucket, tt.t.Location())
+			if !got.Equal(tt.expected) {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
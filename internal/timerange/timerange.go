@@ -0,0 +1,223 @@
+package timerange
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MinBucket is the smallest bucket width accepted for ranged queries.
+	MinBucket = time.Minute
+	// MaxBuckets caps the number of buckets a single ranged query may produce.
+	MaxBuckets = 5000
+	// TargetBuckets is the number of buckets AutoBucket aims to stay under,
+	// matching the density of the fixed 12h/24h/7d/30d windows.
+	TargetBuckets = 36
+	// DefaultLookback is used when no start is supplied.
+	DefaultLookback = 24 * time.Hour
+	// MaxRange caps the length of a range, twenty years.
+	MaxRange = 20 * 366 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidDuration = errors.New("invalid duration")
+	ErrInvalidTime     = errors.New("invalid time")
+	ErrInvalidRange    = errors.New("invalid range")
+	ErrInvalidBucket   = errors.New("invalid bucket")
+)
+
+// bucketLadder is the set of bucket widths AutoBucket chooses from.
+var bucketLadder = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+}
+
+// Range is the half-open time interval [Start, End).
+type Range struct {
+	Start time.Time
+	End   time.Time
+	// Relative is set when the range was resolved against now, so the same
+	// query selects a slightly different range on every request.
+	Relative bool
+}
+
+func (r Range) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// ParseDuration extends time.ParseDuration with day (d) and week (w) units,
+// e.g. "7d", "2w" or "1d12h".
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty", ErrInvalidDuration)
+	}
+
+	var total time.Duration
+	rest := s
+	for rest != "" {
+		i := strings.IndexAny(rest, "dw")
+		if i == -1 {
+			d, err := time.ParseDuration(rest)
+			if err != nil {
+				return 0, fmt.Errorf("%w: %s", ErrInvalidDuration, s)
+			}
+			if d > math.MaxInt64-total {
+				return 0, fmt.Errorf("%w: %s is too long", ErrInvalidDuration, s)
+			}
+			total += d
+			break
+		}
+
+		// only consume a d/w unit when it is preceded by a plain number
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidDuration, s)
+		}
+
+		unit := 24 * time.Hour
+		if rest[i] == 'w' {
+			unit = 7 * 24 * time.Hour
+		}
+		if time.Duration(n) > (math.MaxInt64-total)/unit {
+			return 0, fmt.Errorf("%w: %s is too long", ErrInvalidDuration, s)
+		}
+		total += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+
+	return total, nil
+}
+
+// FormatInterval renders a duration using the largest whole unit, producing
+// strings such as "30m", "6h" or "1d" that are valid Postgres intervals.
+func FormatInterval(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
+
+// ParseTime accepts "now", an RFC3339 timestamp, or a relative duration such as
+// "24h", "-24h" or "7d" which is interpreted as that long before now.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "now") {
+		return now, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	d, err := ParseDuration(strings.TrimPrefix(s, "-"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s is neither RFC3339 nor a relative duration", ErrInvalidTime, s)
+	}
+
+	return now.Add(-d), nil
+}
+
+// Parse builds a Range from start and end query values. An empty end means now
+// and an empty start means DefaultLookback before end.
+func Parse(start string, end string, now time.Time) (Range, error) {
+	e, err := ParseTime(end, now)
+	if err != nil {
+		return Range{}, err
+	}
+
+	var s time.Time
+	if strings.TrimSpace(start) == "" {
+		s = e.Add(-DefaultLookback)
+	} else {
+		s, err = ParseTime(start, now)
+		if err != nil {
+			return Range{}, err
+		}
+	}
+
+	if !s.Before(e) {
+		return Range{}, fmt.Errorf("%w: start %s must be before end %s", ErrInvalidRange, s.Format(time.RFC3339), e.Format(time.RFC3339))
+	}
+
+	if e.After(now.Add(24 * time.Hour)) {
+		return Range{}, fmt.Errorf("%w: end %s is too far in the future", ErrInvalidRange, e.Format(time.RFC3339))
+	}
+
+	if e.Sub(s) > MaxRange {
+		return Range{}, fmt.Errorf("%w: ranges are limited to %s", ErrInvalidRange, FormatInterval(MaxRange))
+	}
+
+	relative := !isTimestamp(end) || (strings.TrimSpace(start) != "" && !isTimestamp(start))
+
+	return Range{Start: s, End: e, Relative: relative}, nil
+}
+
+// isTimestamp reports whether s is an absolute time rather than one relative
+// to now.
+func isTimestamp(s string) bool {
+	_, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	return err == nil
+}
+
+// AutoBucket picks the smallest bucket from the ladder that keeps the range
+// under TargetBuckets buckets.
+func AutoBucket(r Range) time.Duration {
+	for _, b := range bucketLadder {
+		if r.Duration()/b <= TargetBuckets {
+			return b
+		}
+	}
+
+	return bucketLadder[len(bucketLadder)-1]
+}
+
+// ParseBucket parses an explicit bucket width, or picks one with AutoBucket
+// when s is empty, and validates it against the range.
+func ParseBucket(s string, r Range) (time.Duration, error) {
+	if strings.TrimSpace(s) == "" {
+		b := AutoBucket(r)
+		if r.Duration()/b > MaxBuckets {
+			return 0, fmt.Errorf("%w: the range would produce more than %d buckets of %s", ErrInvalidBucket, MaxBuckets, FormatInterval(b))
+		}
+
+		return b, nil
+	}
+
+	b, err := ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidBucket, s)
+	}
+
+	if b < MinBucket {
+		return 0, fmt.Errorf("%w: %s is smaller than %s", ErrInvalidBucket, s, FormatInterval(MinBucket))
+	}
+
+	if b%time.Second != 0 {
+		return 0, fmt.Errorf("%w: %s must be a whole number of seconds", ErrInvalidBucket, s)
+	}
+
+	if r.Duration()/b > MaxBuckets {
+		return 0, fmt.Errorf("%w: %s would produce more than %d buckets", ErrInvalidBucket, s, MaxBuckets)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,187 @@
+package timerange_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  time.Duration
+		expectErr bool
+	}{
+		{name: "Minutes", input: "30m", expected: 30 * time.Minute},
+		{name: "Days", input: "7d", expected: 7 * 24 * time.Hour},
+		{name: "Weeks", input: "2w", expected: 14 * 24 * time.Hour},
+		{name: "Compound", input: "1d12h", expected: 36 * time.Hour},
+		{name: "Empty", input: "", expectErr: true},
+		{name: "Garbage", input: "soon", expectErr: true},
+		{name: "Fractional days", input: "1.5d", expectErr: true},
+		{name: "Overflowing days", input: "106752d", expectErr: true},
+		{name: "Overflowing weeks", input: "9223372036854775807w", expectErr: true},
+		{name: "Overflowing sum", input: "106751d25h", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := timerange.ParseDuration(tt.input)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectErr, err)
+			}
+			if !tt.expectErr && d != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, d)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		start            string
+		end              string
+		expectedStart    time.Time
+		expectedEnd      time.Time
+		expectedRelative bool
+		expectedErr      error
+	}{
+		{
+			name:             "Defaults",
+			expectedStart:    now.Add(-24 * time.Hour),
+			expectedEnd:      now,
+			expectedRelative: true,
+		},
+		{
+			name:             "Relative start",
+			start:            "-7d",
+			expectedStart:    now.Add(-7 * 24 * time.Hour),
+			expectedEnd:      now,
+			expectedRelative: true,
+		},
+		{
+			name:             "Relative start before absolute end",
+			start:            "-30d",
+			end:              "2025-03-01T00:00:00Z",
+			expectedStart:    now.Add(-30 * 24 * time.Hour),
+			expectedEnd:      time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			expectedRelative: true,
+		},
+		{
+			name:          "Default start before absolute end",
+			end:           "2025-03-01T00:00:00Z",
+			expectedStart: time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "Absolute range",
+			start:         "2025-02-01T00:00:00Z",
+			end:           "2025-03-01T00:00:00-07:00",
+			expectedStart: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2025, 3, 1, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "Start after end",
+			start:       "1h",
+			end:         "2h",
+			expectedErr: timerange.ErrInvalidRange,
+		},
+		{
+			name:        "Bad start",
+			start:       "yesterday",
+			expectedErr: timerange.ErrInvalidTime,
+		},
+		{
+			name:        "Too long",
+			start:       "1900-01-01T00:00:00Z",
+			expectedErr: timerange.ErrInvalidRange,
+		},
+		{
+			name:        "Far future",
+			end:         "2030-01-01T00:00:00Z",
+			expectedErr: timerange.ErrInvalidRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := timerange.Parse(tt.start, tt.end, now)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !r.Start.Equal(tt.expectedStart) || !r.End.Equal(tt.expectedEnd) {
+				t.Errorf("expected %s - %s, got %s - %s", tt.expectedStart, tt.expectedEnd, r.Start, r.End)
+			}
+			if r.Relative != tt.expectedRelative {
+				t.Errorf("expected relative %t, got %t", tt.expectedRelative, r.Relative)
+			}
+		})
+	}
+}
+
+func TestParseBucket(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		lookback    time.Duration
+		bucket      string
+		expected    time.Duration
+		expectedErr error
+	}{
+		{name: "Auto 12h", lookback: 12 * time.Hour, expected: 30 * time.Minute},
+		{name: "Auto 24h", lookback: 24 * time.Hour, expected: time.Hour},
+		{name: "Auto 7d", lookback: 7 * 24 * time.Hour, expected: 6 * time.Hour},
+		{name: "Auto 30d", lookback: 30 * 24 * time.Hour, expected: 24 * time.Hour},
+		{name: "Explicit", lookback: 24 * time.Hour, bucket: "15m", expected: 15 * time.Minute},
+		{name: "Too small", lookback: 24 * time.Hour, bucket: "10s", expectedErr: timerange.ErrInvalidBucket},
+		{name: "Too many buckets", lookback: 365 * 24 * time.Hour, bucket: "1m", expectedErr: timerange.ErrInvalidBucket},
+		{name: "Auto too many buckets", lookback: 100 * 366 * 24 * time.Hour, expectedErr: timerange.ErrInvalidBucket},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := timerange.Range{Start: now.Add(-tt.lookback), End: now}
+			b, err := timerange.ParseBucket(tt.bucket, r)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, b)
+			}
+		})
+	}
+}
+
+func TestFormatInterval(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Minute: "30m",
+		6 * time.Hour:    "6h",
+		24 * time.Hour:   "1d",
+		90 * time.Second: "90s",
+	}
+
+	for d, expected := range tests {
+		if got := timerange.FormatInterval(d); got != expected {
+			t.Errorf("FormatInterval(%s): expected %s, got %s", d, expected, got)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package timescale
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidAggregation = errors.New("invalid aggregation")
+
+// BucketAggregation is an aggregation computed per bucket of a ranged query
+// in addition to avg, min and max. Percentiles are named pN, e.g. p5 or p99.9.
+type BucketAggregation string
+
+const (
+	AggregateCount  BucketAggregation = "count"
+	AggregateSum    BucketAggregation = "sum"
+	AggregateStddev BucketAggregation = "stddev"
+	AggregateMedian BucketAggregation = "median"
+	AggregateFirst  BucketAggregation = "first"
+	AggregateLast   BucketAggregation = "last"
+)
+
+// BucketAggregations are the named bucket aggregations, percentiles aside.
+var BucketAggregations = []BucketAggregation{
+	AggregateCount,
+	AggregateSum,
+	AggregateStddev,
+	AggregateMedian,
+	AggregateFirst,
+	AggregateLast,
+}
+
+// Percentile returns the fraction of a pN aggregation. N is one or two digits
+// with an optional decimal fraction, e.g. p5, p95 or p99.9.
+func (a BucketAggregation) Percentile() (float64, bool) {
+	p, ok := a.percentile()
+	return p / 100, ok
+}
+
+// percentile returns the N of a pN aggregation.
+func (a BucketAggregation) percentile() (float64, bool) {
+	s, ok := strings.CutPrefix(string(a), "p")
+	if !ok {
+		return 0, false
+	}
+
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if whole == "" || len(whole) > 2 || !isDigits(whole) || (hasFraction && (fraction == "" || !isDigits(fraction))) {
+		return 0, false
+	}
+
+	// a long fraction can still round up to 100
+	p, err := strconv.ParseFloat(s, 64)
+	if err != nil || p <= 0 || p >= 100 {
+		return 0, false
+	}
+
+	return p, true
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseBucketAggregation validates a single aggregation name. Percentiles are
+// returned in their canonical form, e.g. p95.0 as p95, so that each is cached
+// and labelled once.
+func ParseBucketAggregation(s string) (BucketAggregation, error) {
+	a := BucketAggregation(strings.ToLower(strings.TrimSpace(s)))
+
+	for _, named := range BucketAggregations {
+		if a == named {
+			return a, nil
+		}
+	}
+
+	if p, ok := a.percentile(); ok {
+		return BucketAggregation("p" + strconv.FormatFloat(p, 'f', -1, 64)), nil
+	}
+
+	return "", fmt.Errorf("%w: %q is not one of count, sum, stddev, median, first, last or a percentile like p95", ErrInvalidAggregation, s)
+}
+
+// ParseBucketAggregations parses a comma separated ?agg= value, dropping
+// duplicates.
+func ParseBucketAggregations(s string) ([]BucketAggregation, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var aggregations []BucketAggregation
+	seen := map[BucketAggregation]bool{}
+
+	for _, part := range strings.Split(s, ",") {
+		a, err := ParseBucketAggregation(part)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[a] {
+			seen[a] = true
+			aggregations = append(aggregations, a)
+		}
+	}
+
+	return aggregations, nil
+}
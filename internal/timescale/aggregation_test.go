@@ -0,0 +1,59 @@
+package timescale_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+)
+
+func TestParseBucketAggregations(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    []timescale.BucketAggregation
+		expectedErr error
+	}{
+		{name: "Empty", input: "", expected: nil},
+		{name: "Named", input: "count,sum,stddev", expected: []timescale.BucketAggregation{timescale.AggregateCount, timescale.AggregateSum, timescale.AggregateStddev}},
+		{name: "Percentiles", input: "p5, P95,p99.9", expected: []timescale.BucketAggregation{"p5", "p95", "p99.9"}},
+		{name: "Duplicates", input: "median,median", expected: []timescale.BucketAggregation{timescale.AggregateMedian}},
+		{name: "Percentile out of range", input: "p100", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Percentile NaN", input: "pnan", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Percentile Inf", input: "pinf", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Percentile negative Inf", input: "p-inf", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Percentile canonical form", input: "p95.0,p09,p99.90", expected: []timescale.BucketAggregation{"p95", "p9", "p99.9"}},
+		{name: "Percentile spellings of one aggregation", input: "p95,p95.0", expected: []timescale.BucketAggregation{"p95"}},
+		{name: "Percentile exponent", input: "p9.5e1", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Percentile three digits", input: "p095", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Percentile sign", input: "p+5", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Percentile empty fraction", input: "p5.", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Percentile rounding to 100", input: "p99.99999999999999999", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Percentile zero", input: "p0.0", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Unknown", input: "mode", expectedErr: timescale.ErrInvalidAggregation},
+		{name: "Injection", input: "p5) FROM pg_user; --", expectedErr: timescale.ErrInvalidAggregation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := timescale.ParseBucketAggregations(tt.input)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("expected %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package timescale
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+const (
+	aqiTable  = "airgradient_aqi"
+	aqiColumn = "aqi"
+)
+
+// GetDailyExtremesTemplateParameters selects local calendar days over the
+// whole history, only those on Month and Day when both are set.
+type GetDailyExtremesTemplateParameters struct {
+	Location     *time.Location
+	Month        int
+	Day          int
+	SerialNumber string
+}
+
+// DailyExtremes are the extremes of a local calendar day. Values are nil when
+// the day has no readings of them.
+type DailyExtremes struct {
+	Date                string     `json:"date"`
+	HighTemperature     *float64   `json:"high_temperature"`
+	HighTemperatureTime *time.Time `json:"high_temperature_time"`
+	LowTemperature      *float64   `json:"low_temperature"`
+	LowTemperatureTime  *time.Time `json:"low_temperature_time"`
+	MaxGust             *float64   `json:"max_gust"`
+	MaxGustTime         *time.Time `json:"max_gust_time"`
+	Rain                *float64   `json:"rain"`
+	MaxAQI              *float64   `json:"max_aqi"`
+	MaxAQITime          *time.Time `json:"max_aqi_time"`
+}
+
+// GetDailyExtremes returns the extremes of every recorded day, ordered by
+// date. It reads the full history and is not cached, callers are expected to
+// cache what they compute from it.
+func (c *TimescaleClient) GetDailyExtremes(ctx context.Context, tp GetDailyExtremesTemplateParameters) ([]DailyExtremes, error) {
+	days := map[string]*DailyExtremes{}
+
+	query, err := buildGetDailyWeatherExtremesQuery(tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	rows, err := c.Pool.Query(ctx, query.String(), query.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily weather extremes: %w", err)
+	}
+
+	for rows.Next() {
+		var (
+			row DailyExtremes
+			day time.Time
+		)
+		err := rows.Scan(
+			&day,
+			&row.HighTemperature, &row.HighTemperatureTime,
+			&row.LowTemperature, &row.LowTemperatureTime,
+			&row.MaxGust, &row.MaxGustTime,
+			&row.Rain,
+		)
+		if err != nil {
+			slog.Error("failed to scan row", slog.String("error", err.Error()))
+			continue
+		}
+
+		row.Date = day.Format(time.DateOnly)
+		days[row.Date] = &row
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read daily weather extremes: %w", err)
+	}
+
+	if tp.SerialNumber != "" {
+		query, err := buildGetDailyAQIExtremesQuery(tp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build query: %w", err)
+		}
+
+		slog.Debug("query", slog.String("query", query.String()))
+
+		rows, err := c.Pool.Query(ctx, query.String(), query.args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get daily aqi extremes: %w", err)
+		}
+
+		for rows.Next() {
+			var (
+				day     time.Time
+				maxAQI  *float64
+				maxTime *time.Time
+			)
+			err := rows.Scan(&day, &maxAQI, &maxTime)
+			if err != nil {
+				slog.Error("failed to scan row", slog.String("error", err.Error()))
+				continue
+			}
+
+			date := day.Format(time.DateOnly)
+			row, ok := days[date]
+			if !ok {
+				row = &DailyExtremes{Date: date}
+				days[date] = row
+			}
+			row.MaxAQI = maxAQI
+			row.MaxAQITime = maxTime
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read daily aqi extremes: %w", err)
+		}
+	}
+
+	dailyExtremes := make([]DailyExtremes, 0, len(days))
+	for _, row := range days {
+		for _, t := range []*time.Time{row.HighTemperatureTime, row.LowTemperatureTime, row.MaxGustTime, row.MaxAQITime} {
+			if t != nil {
+				*t = t.In(tp.Location)
+			}
+		}
+		dailyExtremes = append(dailyExtremes, *row)
+	}
+
+	sort.Slice(dailyExtremes, func(i, j int) bool {
+		return dailyExtremes[i].Date < dailyExtremes[j].Date
+	})
+
+	return dailyExtremes, nil
+}
@@ -1,7 +1,6 @@
 package timescale
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,13 +8,16 @@ import (
 	"log/slog"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/redis/go-redis/v9"
-
-	_ "embed"
 )
 
+// MaxBirdnetLookback bounds the lookback of client chosen bird counts, each
+// of which scans and groups the detections of the whole lookback.
+const MaxBirdnetLookback = 366 * 24 * time.Hour
+
 type GetBirdnetTemplateParameters struct {
 	LookbackInterval string
 }
@@ -33,9 +35,6 @@ func (t *GetBirdnetTemplateParameters) Hash() string {
 	return strconv.FormatUint(xxhash.Sum64String(t.String()), 16)
 }
 
-//go:embed queries/getbirdnet.pgsql.gotmpl
-var getBirdnetTemplate string
-
 func (c *TimescaleClient) GetBirdnet(ctx context.Context, tp GetBirdnetTemplateParameters) ([]GetBirdnetResponse, error) {
 	if c.Dfly != nil {
 		res, err := c.Dfly.GetClient().Get(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash())).Result()
@@ -51,22 +50,47 @@ func (c *TimescaleClient) GetBirdnet(ctx context.Context, tp GetBirdnetTemplateP
 		}
 	}
 
-	query := bytes.NewBuffer(nil)
-	err := c.getBirdnetTemplate.Execute(query, tp)
+	var getBirdnetResponses []GetBirdnetResponse
+
+	err := c.StreamBirdnet(ctx, tp, func(row GetBirdnetResponse) error {
+		getBirdnetResponses = append(getBirdnetResponses, row)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query template: %w", err)
+		return nil, err
+	}
+
+	if c.Dfly != nil {
+		getBirdnetResponsesJSON, err := json.Marshal(getBirdnetResponses)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := c.Dfly.GetClient().Set(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash()), getBirdnetResponsesJSON, c.Dfly.CacheResultsDuration).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return getBirdnetResponses, nil
+}
+
+// StreamBirdnet counts birds without the cache, calling fn with each species
+// as it is read. It stops at the first error fn returns.
+func (c *TimescaleClient) StreamBirdnet(ctx context.Context, tp GetBirdnetTemplateParameters, fn func(GetBirdnetResponse) error) error {
+	query, err := buildGetBirdnetQuery(tp)
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
 	}
 
 	slog.Debug("query", slog.String("query", query.String()))
 
-	rows, err := c.Pool.Query(ctx, query.String())
+	rows, err := c.Pool.Query(ctx, query.String(), query.args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get birds for the last %s: %w", tp.LookbackInterval, err)
+		return fmt.Errorf("failed to get birds for the last %s: %w", tp.LookbackInterval, err)
 	}
 	defer rows.Close()
 
-	var getBirdnetResponses []GetBirdnetResponse
-
 	for rows.Next() {
 		var row GetBirdnetResponse
 		err := rows.Scan(&row.CommonName, &row.Count)
@@ -75,20 +99,16 @@ func (c *TimescaleClient) GetBirdnet(ctx context.Context, tp GetBirdnetTemplateP
 			continue
 		}
 
-		getBirdnetResponses = append(getBirdnetResponses, row)
-	}
-
-	if c.Dfly != nil {
-		getBirdnetResponsesJSON, err := json.Marshal(getBirdnetResponses)
+		err = fn(row)
 		if err != nil {
-			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
-		} else {
-			err := c.Dfly.GetClient().Set(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash()), getBirdnetResponsesJSON, c.Dfly.CacheResultsDuration).Err()
-			if err != nil {
-				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
-			}
+			return err
 		}
 	}
 
-	return getBirdnetResponses, nil
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("failed to read birds for the last %s: %w", tp.LookbackInterval, err)
+	}
+
+	return nil
 }
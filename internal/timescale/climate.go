@@ -0,0 +1,141 @@
+package timescale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	climateTable             = "vantagepro2plus"
+	climateTemperatureColumn = "temperature"
+	climateHumidityColumn    = "humidity"
+	climatePressureColumn    = "barometer_sea_level"
+	climateGustColumn        = "wind_speed_high_last_10_min"
+)
+
+// GetClimateDailyTemplateParameters summarizes the local days between Start
+// and End, which are expected to be midnights in Location. A Relative range,
+// resolved against now, is cached to the minute.
+type GetClimateDailyTemplateParameters struct {
+	Start    time.Time
+	End      time.Time
+	Location *time.Location
+	Relative bool
+}
+
+func (t *GetClimateDailyTemplateParameters) String() string {
+	return fmt.Sprintf("climatedaily-%s-%s",
+		rangeKey(t.Start, t.End, t.Relative, timerange.MinBucket),
+		strings.ReplaceAll(t.Location.String(), " ", ""))
+}
+
+func (t *GetClimateDailyTemplateParameters) Hash() string {
+	return strconv.FormatUint(xxhash.Sum64String(t.String()), 16)
+}
+
+// GetClimateDailyResponse is the summary of a local calendar day. Extremes
+// and averages are nil on days without readings, and the times of extremes
+// are the first time they were reached.
+type GetClimateDailyResponse struct {
+	Date                string            `json:"date"`
+	HighTemperature     *float64          `json:"high_temperature"`
+	HighTemperatureTime *time.Time        `json:"high_temperature_time"`
+	LowTemperature      *float64          `json:"low_temperature"`
+	LowTemperatureTime  *time.Time        `json:"low_temperature_time"`
+	MaxGust             *float64          `json:"max_gust"`
+	MaxGustTime         *time.Time        `json:"max_gust_time"`
+	Rain                float64           `json:"rain"`
+	AvgHumidity         *float64          `json:"avg_humidity"`
+	AvgPressure         *float64          `json:"avg_pressure"`
+	Units               map[string]string `json:"units,omitempty"`
+}
+
+// GetClimateDaily summarizes temperature, gusts, rain, humidity and pressure
+// per local calendar day.
+func (c *TimescaleClient) GetClimateDaily(ctx context.Context, tp GetClimateDailyTemplateParameters) ([]GetClimateDailyResponse, error) {
+	if c.Dfly != nil {
+		res, err := c.Dfly.GetClient().Get(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash())).Result()
+		if err == nil {
+			var getClimateDailyResponses []GetClimateDailyResponse
+			err := json.Unmarshal([]byte(res), &getClimateDailyResponses)
+			if err != nil {
+				slog.Error("failed to unmarshal from dragonfly", slog.String("error", err.Error()))
+			}
+			return getClimateDailyResponses, nil
+		} else if !errors.Is(err, redis.Nil) {
+			slog.Error("failed to get from dragonfly", slog.String("error", err.Error()))
+		}
+	}
+
+	query, err := buildGetClimateDailyQuery(tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	rows, err := c.Pool.Query(ctx, query.String(), query.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily climate from %s to %s: %w", tp.Start.Format(time.RFC3339), tp.End.Format(time.RFC3339), err)
+	}
+	defer rows.Close()
+
+	var getClimateDailyResponses []GetClimateDailyResponse
+
+	for rows.Next() {
+		var (
+			row GetClimateDailyResponse
+			day time.Time
+		)
+		err := rows.Scan(
+			&day,
+			&row.HighTemperature, &row.HighTemperatureTime,
+			&row.LowTemperature, &row.LowTemperatureTime,
+			&row.MaxGust, &row.MaxGustTime,
+			&row.Rain,
+			&row.AvgHumidity,
+			&row.AvgPressure,
+		)
+		if err != nil {
+			slog.Error("failed to scan row", slog.String("error", err.Error()))
+			continue
+		}
+
+		row.Date = day.Format(time.DateOnly)
+		for _, t := range []*time.Time{row.HighTemperatureTime, row.LowTemperatureTime, row.MaxGustTime} {
+			if t != nil {
+				*t = t.In(tp.Location)
+			}
+		}
+
+		getClimateDailyResponses = append(getClimateDailyResponses, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read daily climate from %s to %s: %w", tp.Start.Format(time.RFC3339), tp.End.Format(time.RFC3339), err)
+	}
+
+	if c.Dfly != nil {
+		getClimateDailyResponsesJSON, err := json.Marshal(getClimateDailyResponses)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := c.Dfly.GetClient().Set(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash()), getClimateDailyResponsesJSON, c.Dfly.CacheResultsDuration).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return getClimateDailyResponses, nil
+}
@@ -0,0 +1,137 @@
+package timescale
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+)
+
+// GetColumnsTemplateParameters selects the raw readings of several columns of
+// one table, for values computed from more than one column. The range is
+// chosen like GetColumnTemplateParameters.
+type GetColumnsTemplateParameters struct {
+	ColumnNames      []string
+	LookbackInterval string
+	Start            time.Time
+	End              time.Time
+	TableName        string
+	SerialNumber     string
+	// Relative marks a range resolved against now. Its cache key is rounded
+	// down to the minute so repeated requests share an entry.
+	Relative bool
+}
+
+func (t *GetColumnsTemplateParameters) String() string {
+	lookback := strings.ReplaceAll(t.LookbackInterval, " ", "")
+	if !t.Start.IsZero() {
+		lookback = rangeKey(t.Start, t.End, t.Relative, timerange.MinBucket)
+	}
+
+	return fmt.Sprintf("%s-%s-%s-%s",
+		strings.ReplaceAll(strings.Join(t.ColumnNames, ","), " ", ""),
+		lookback,
+		strings.ReplaceAll(t.TableName, " ", ""),
+		strings.ReplaceAll(t.SerialNumber, " ", ""))
+}
+
+func (t *GetColumnsTemplateParameters) Hash() string {
+	return strconv.FormatUint(xxhash.Sum64String(t.String()), 16)
+}
+
+// Expression is a value computed in sql from several columns of the same row,
+// such as a derived metric.
+type Expression struct {
+	Name    string
+	Columns []string
+	// SQL returns the expression given a float8 sql expression for each of
+	// Columns, in order.
+	SQL func(columns []string) string
+}
+
+type GetColumnsLastTemplateParameters struct {
+	ColumnNames  []string
+	TableName    string
+	SerialNumber string
+}
+
+// GetColumnsResponse is one row of readings, Values holds one entry per
+// requested column in order and is nil where the reading is missing.
+type GetColumnsResponse struct {
+	Time   time.Time
+	Values []*float64
+}
+
+func scanColumnsRow(row pgx.Row, columns int) (GetColumnsResponse, error) {
+	response := GetColumnsResponse{
+		Values: make([]*float64, columns),
+	}
+
+	dest := make([]any, 0, columns+1)
+	dest = append(dest, &response.Time)
+	for i := range response.Values {
+		dest = append(dest, &response.Values[i])
+	}
+
+	err := row.Scan(dest...)
+
+	return response, err
+}
+
+// GetColumns returns raw, unaggregated readings. Results are not cached;
+// callers cache whatever they compute from them.
+func (c *TimescaleClient) GetColumns(ctx context.Context, tp GetColumnsTemplateParameters) ([]GetColumnsResponse, error) {
+	query, err := buildGetColumnsQuery(tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	rows, err := c.Pool.Query(ctx, query.String(), query.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", strings.Join(tp.ColumnNames, ", "), err)
+	}
+	defer rows.Close()
+
+	var getColumnsResponses []GetColumnsResponse
+
+	for rows.Next() {
+		row, err := scanColumnsRow(rows, len(tp.ColumnNames))
+		if err != nil {
+			slog.Error("failed to scan row", slog.String("error", err.Error()))
+			continue
+		}
+
+		getColumnsResponses = append(getColumnsResponses, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", strings.Join(tp.ColumnNames, ", "), err)
+	}
+
+	return getColumnsResponses, nil
+}
+
+// GetColumnsLast returns the latest row of several columns in a single query.
+func (c *TimescaleClient) GetColumnsLast(ctx context.Context, tp GetColumnsLastTemplateParameters) (*GetColumnsResponse, error) {
+	query, err := buildGetColumnsLastQuery(tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	row, err := scanColumnsRow(c.Pool.QueryRow(ctx, query.String(), query.args...), len(tp.ColumnNames))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", strings.Join(tp.ColumnNames, ", "), err)
+	}
+
+	return &row, nil
+}
@@ -0,0 +1,135 @@
+package timescale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// exportCursor names the server side cursor of an export.
+	exportCursor = "export"
+	// exportFetchRows is how many rows each fetch from the cursor reads.
+	exportFetchRows = 1000
+)
+
+var ErrNotExportable = errors.New("table is not exportable")
+
+// ExportTables are the tables whose raw readings can be exported.
+var ExportTables = []string{"vantagepro2plus", "airgradient", "birdnet"}
+
+// ExportTemplateParameters selects the raw rows of a table in [Start, End).
+// Cursor resumes an export, skipping the rows before it.
+type ExportTemplateParameters struct {
+	TableName string
+	Start     time.Time
+	End       time.Time
+	Cursor    time.Time
+}
+
+// Export streams every row of the range in time order as a json object with
+// a key per column, calling fn as the rows are read. Rows are fetched from a
+// server side cursor a page at a time, so memory stays constant however long
+// the range, and the cache is bypassed. It stops at the first error fn
+// returns.
+func (c *TimescaleClient) Export(ctx context.Context, tp ExportTemplateParameters, fn func(json.RawMessage) error) error {
+	query, err := buildExportQuery(tp)
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	tx, err := c.Pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to begin export: %w", err)
+	}
+	// the transaction only reads, rolling it back closes the cursor
+	defer func() {
+		err := tx.Rollback(context.Background())
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to end export", slog.String("error", err.Error()))
+		}
+	}()
+
+	// timestamps are rendered in utc whatever the session's time zone
+	_, err = tx.Exec(ctx, "SET LOCAL TimeZone = 'UTC'")
+	if err != nil {
+		return fmt.Errorf("failed to set export time zone: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", exportCursor, query.String()), query.args...)
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", tp.TableName, err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", exportFetchRows, exportCursor)
+
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", tp.TableName, err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+
+			var row json.RawMessage
+			err := rows.Scan(&row)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan %s: %w", tp.TableName, err)
+			}
+
+			err = fn(row)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+
+		err = rows.Err()
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", tp.TableName, err)
+		}
+
+		if fetched < exportFetchRows {
+			return nil
+		}
+	}
+}
+
+func buildExportQuery(tp ExportTemplateParameters) (*query, error) {
+	if !slices.Contains(ExportTables, tp.TableName) {
+		return nil, fmt.Errorf("%w: %q", ErrNotExportable, tp.TableName)
+	}
+
+	table, err := tableIdentifier(tp.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	start := tp.Start
+	if tp.Cursor.After(start) {
+		start = tp.Cursor
+	}
+
+	q.where(`"time" >= %s`, q.arg(start))
+	q.where(`"time" < %s`, q.arg(tp.End))
+
+	q.write("SELECT to_jsonb(t) FROM %s AS t", table)
+	q.writeWhere()
+	q.write(` ORDER BY "time"`)
+
+	return q, nil
+}
@@ -0,0 +1,34 @@
+package timescale
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBuildExportQuery(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	cursor := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	q, err := buildExportQuery(ExportTemplateParameters{TableName: "airgradient", Start: start, End: end, Cursor: cursor})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT to_jsonb(t) FROM "sensors"."airgradient" AS t WHERE "time" >= $1 AND "time" < $2 ORDER BY "time"`
+	if q.String() != expected {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", expected, q.String())
+	}
+
+	if q.args[0] != cursor {
+		t.Errorf("expected the export to resume from %s, got %v", cursor, q.args[0])
+	}
+
+	for _, table := range []string{"airgradient_aqi", `birdnet" --`} {
+		_, err = buildExportQuery(ExportTemplateParameters{TableName: table, Start: start, End: end})
+		if !errors.Is(err, ErrNotExportable) {
+			t.Errorf("%s: expected %v, got %v", table, ErrNotExportable, err)
+		}
+	}
+}
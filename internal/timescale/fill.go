@@ -0,0 +1,152 @@
+package timescale
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+)
+
+var ErrInvalidFill = errors.New("invalid fill")
+
+// Fill is how buckets without readings are filled in ranged queries. Every
+// bucket of the range is returned, and empty buckets are null by default.
+type Fill string
+
+const (
+	FillNull Fill = "null"
+	// FillLOCF carries the last observation forward.
+	FillLOCF Fill = "locf"
+	// FillLinear interpolates linearly between the surrounding buckets.
+	FillLinear Fill = "linear"
+)
+
+// ParseFill validates a ?fill= value, an empty value means FillNull.
+func ParseFill(s string) (Fill, error) {
+	switch f := Fill(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FillNull, nil
+	case FillNull, FillLOCF, FillLinear:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %q is not one of null, locf or linear", ErrInvalidFill, s)
+	}
+}
+
+// wrap applies the fill to an aggregate expression of a gapfilled query.
+func (f Fill) wrap(expression string) string {
+	switch f {
+	case FillLOCF:
+		return fmt.Sprintf("locf(%s)", expression)
+	case FillLinear:
+		return fmt.Sprintf("interpolate(%s)", expression)
+	default:
+		return expression
+	}
+}
+
+// filledValue reads and writes one of the filled values of a bucket.
+type filledValue struct {
+	get func(r *GetColumnResponse) *float64
+	set func(r *GetColumnResponse, v *float64)
+}
+
+// filledValues returns the nullable values of a bucket that are filled.
+// Counts are not filled, an empty bucket has no readings to count.
+func filledValues(aggregations []BucketAggregation) []filledValue {
+	values := []filledValue{
+		{get: func(r *GetColumnResponse) *float64 { return r.Avg }, set: func(r *GetColumnResponse, v *float64) { r.Avg = v }},
+		{get: func(r *GetColumnResponse) *float64 { return r.Min }, set: func(r *GetColumnResponse, v *float64) { r.Min = v }},
+		{get: func(r *GetColumnResponse) *float64 { return r.Max }, set: func(r *GetColumnResponse, v *float64) { r.Max = v }},
+	}
+
+	for _, a := range aggregations {
+		if a == AggregateCount {
+			continue
+		}
+
+		values = append(values, filledValue{
+			get: func(r *GetColumnResponse) *float64 { return r.Aggregate(a) },
+			set: func(r *GetColumnResponse, v *float64) { r.SetAggregate(a, v) },
+		})
+	}
+
+	return values
+}
+
+// FillGaps does in Go what time_bucket_gapfill does in sql, for series
+// bucketed outside the database. buckets must be ordered and aligned to
+// bucket on the wall clock of loc, and every bucket from start to end is
+// returned.
+func FillGaps(buckets []GetColumnResponse, start time.Time, end time.Time, bucket time.Duration, loc *time.Location, aggregations []BucketAggregation, fill Fill) []GetColumnResponse {
+	if bucket <= 0 {
+		return buckets
+	}
+
+	byTime := make(map[int64]GetColumnResponse, len(buckets))
+	for _, b := range buckets {
+		byTime[b.Time.UnixNano()] = b
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var filled []GetColumnResponse
+	for t := timerange.TruncateIn(start, bucket, loc); t.Before(end); t = timerange.NextBucketIn(t, bucket, loc) {
+		b, ok := byTime[t.UnixNano()]
+		if !ok {
+			b = GetColumnResponse{Time: t}
+			for _, a := range aggregations {
+				b.SetAggregate(a, nil)
+			}
+		}
+		filled = append(filled, b)
+	}
+
+	if fill != FillLOCF && fill != FillLinear {
+		return filled
+	}
+
+	for _, value := range filledValues(aggregations) {
+		previous := -1
+
+		for i := range filled {
+			if value.get(&filled[i]) != nil {
+				previous = i
+				continue
+			}
+
+			if previous < 0 {
+				continue
+			}
+
+			from := *value.get(&filled[previous])
+
+			if fill == FillLOCF {
+				value.set(&filled[i], &from)
+				continue
+			}
+
+			next := -1
+			for j := i + 1; j < len(filled); j++ {
+				if value.get(&filled[j]) != nil {
+					next = j
+					break
+				}
+			}
+
+			if next < 0 {
+				break
+			}
+
+			to := *value.get(&filled[next])
+			interpolated := from + (to-from)*float64(i-previous)/float64(next-previous)
+			value.set(&filled[i], &interpolated)
+		}
+	}
+
+	return filled
+}
@@ -0,0 +1,86 @@
+package timescale_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+)
+
+func TestParseFill(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    timescale.Fill
+		expectedErr error
+	}{
+		{input: "", expected: timescale.FillNull},
+		{input: "null", expected: timescale.FillNull},
+		{input: "LOCF", expected: timescale.FillLOCF},
+		{input: "linear", expected: timescale.FillLinear},
+		{input: "zero", expectedErr: timescale.ErrInvalidFill},
+		{input: "locf(1); --", expectedErr: timescale.ErrInvalidFill},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := timescale.ParseFill(tt.input)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFillGaps(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+	base := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	buckets := []timescale.GetColumnResponse{
+		{Time: base.Add(time.Hour), Avg: value(1), Min: value(1), Max: value(1)},
+		{Time: base.Add(4 * time.Hour), Avg: value(4), Min: value(4), Max: value(4)},
+	}
+
+	tests := []struct {
+		fill     timescale.Fill
+		expected []*float64
+	}{
+		{fill: timescale.FillNull, expected: []*float64{nil, value(1), nil, nil, value(4), nil}},
+		{fill: timescale.FillLOCF, expected: []*float64{nil, value(1), value(1), value(1), value(4), value(4)}},
+		{fill: timescale.FillLinear, expected: []*float64{nil, value(1), value(2), value(3), value(4), nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.fill), func(t *testing.T) {
+			got := timescale.FillGaps(buckets, base.Add(10*time.Minute), base.Add(6*time.Hour), time.Hour, nil, nil, tt.fill)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %d buckets, got %d", len(tt.expected), len(got))
+			}
+
+			for i, e := range tt.expected {
+				if !got[i].Time.Equal(base.Add(time.Duration(i) * time.Hour)) {
+					t.Errorf("bucket %d: unexpected time %s", i, got[i].Time)
+				}
+
+				a := got[i].Avg
+				switch {
+				case e == nil && a != nil:
+					t.Errorf("bucket %d: expected null, got %f", i, *a)
+				case e != nil && a == nil:
+					t.Errorf("bucket %d: expected %f, got null", i, *e)
+				case e != nil && *a != *e:
+					t.Errorf("bucket %d: expected %f, got %f", i, *e, *a)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package timescale
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// ReadingsChannel is notified with the table name whenever rows are
+	// inserted into one of NotifyTables.
+	ReadingsChannel = "lfpweather_readings"
+
+	notifyName = "lfpweather_notify"
+)
+
+// NotifyTables are the tables that notify ReadingsChannel once the triggers
+// created by ManageNotify exist.
+var NotifyTables = []string{"vantagepro2plus", "airgradient", "airgradient_aqi"}
+
+func notifyFunction() string {
+	return pgx.Identifier{schemaName, notifyName}.Sanitize()
+}
+
+// NotifyCreateStatements create a trigger function and a statement level
+// trigger on every notifying table, so a batch of inserted rows notifies once.
+func NotifyCreateStatements() ([]string, error) {
+	statements := []string{
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s() RETURNS trigger LANGUAGE plpgsql AS $$ BEGIN PERFORM pg_notify('%s', TG_TABLE_NAME); RETURN NULL; END $$`,
+			notifyFunction(), ReadingsChannel),
+	}
+
+	for _, t := range NotifyTables {
+		table, err := tableIdentifier(t)
+		if err != nil {
+			return nil, err
+		}
+
+		trigger := pgx.Identifier{notifyName}.Sanitize()
+		statements = append(statements,
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", trigger, table),
+			fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT ON %s FOR EACH STATEMENT EXECUTE FUNCTION %s()", trigger, table, notifyFunction()))
+	}
+
+	return statements, nil
+}
+
+// NotifyDropStatement drops the trigger function and with it every trigger.
+func NotifyDropStatement() string {
+	return fmt.Sprintf("DROP FUNCTION IF EXISTS %s() CASCADE", notifyFunction())
+}
+
+// ManageNotify creates or drops the triggers that notify ReadingsChannel.
+func (c *TimescaleClient) ManageNotify(ctx context.Context, action string) error {
+	var statements []string
+
+	switch action {
+	case "create":
+		created, err := NotifyCreateStatements()
+		if err != nil {
+			return fmt.Errorf("failed to build notify triggers: %w", err)
+		}
+		statements = created
+	case "drop":
+		statements = []string{NotifyDropStatement()}
+	default:
+		return fmt.Errorf("unknown notify action %q", action)
+	}
+
+	for _, statement := range statements {
+		slog.Debug("statement", slog.String("statement", statement))
+
+		_, err := c.Pool.Exec(ctx, statement)
+		if err != nil {
+			return fmt.Errorf("failed to %s notify triggers: %w", action, err)
+		}
+	}
+
+	slog.Info("managed notify triggers", slog.String("action", action))
+
+	return nil
+}
+
+// Listen holds a connection listening on channel and calls fn with the
+// payload of every notification, until ctx is done or the connection fails.
+func (c *TimescaleClient) Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	conn, err := c.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire a connection: %w", err)
+	}
+	// the connection is closed rather than released, it is still listening
+	defer func() {
+		_ = conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for notifications on %s: %w", channel, err)
+		}
+
+		fn(notification.Payload)
+	}
+}
@@ -0,0 +1,26 @@
+package timescale
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNotifyCreateStatements(t *testing.T) {
+	statements, err := NotifyCreateStatements()
+	if err != nil {
+		t.Fatalf("failed to build statements: %v", err)
+	}
+
+	if len(statements) != 1+2*len(NotifyTables) {
+		t.Fatalf("expected a function and two statements per table, got %d statements", len(statements))
+	}
+
+	if !strings.Contains(statements[0], `pg_notify('lfpweather_readings', TG_TABLE_NAME)`) {
+		t.Errorf("expected the function to notify the table name, got %s", statements[0])
+	}
+
+	expected := `CREATE TRIGGER "lfpweather_notify" AFTER INSERT ON "sensors"."vantagepro2plus" FOR EACH STATEMENT EXECUTE FUNCTION "sensors"."lfpweather_notify"()`
+	if statements[2] != expected {
+		t.Errorf("expected %s, got %s", expected, statements[2])
+	}
+}
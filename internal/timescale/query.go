@@ -0,0 +1,797 @@
+package timescale
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+)
+
+const schemaName = "sensors"
+
+var (
+	ErrUnknownTable        = errors.New("unknown table")
+	ErrUnknownColumn       = errors.New("unknown column")
+	ErrInvalidInterval     = errors.New("invalid interval")
+	ErrMissingSerialNumber = errors.New("missing serial number")
+	ErrInvalidPeriod       = errors.New("invalid period")
+)
+
+type tableSpec struct {
+	columns map[string]struct{}
+	// serialNumber marks tables shared by several devices and filtered by serial_number.
+	serialNumber bool
+}
+
+func columnSet(columns ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(columns))
+	for _, column := range columns {
+		set[column] = struct{}{}
+	}
+	return set
+}
+
+// tables is the allowlist of every table and column that may appear in a query.
+// Identifiers are never taken from anywhere else.
+var tables = map[string]tableSpec{
+	"vantagepro2plus": {
+		columns: columnSet(
+			"temperature",
+			"humidity",
+			"barometer_sea_level",
+			"solar_radiation",
+			"wind_speed_last",
+			"wind_speed_high_last_10_min",
+			"wind_direction_last",
+			"rain_rate_last",
+			"rain_last_24_hour",
+			"rain_daily",
+			"uv_index",
+		),
+	},
+	"airgradient": {
+		columns:      columnSet("rco2", "nox_index", "tvoc_index"),
+		serialNumber: true,
+	},
+	"airgradient_aqi": {
+		columns:      columnSet("aqi"),
+		serialNumber: true,
+	},
+	"birdnet": {
+		columns: columnSet("common_name"),
+	},
+}
+
+// ValidateColumn reports whether table and column are in the query allowlist.
+func ValidateColumn(table string, column string) error {
+	spec, ok := tables[table]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTable, table)
+	}
+
+	if _, ok := spec.columns[column]; !ok {
+		return fmt.Errorf("%w: %q in table %q", ErrUnknownColumn, column, table)
+	}
+
+	return nil
+}
+
+// query is a sql statement with its positional bind arguments.
+type query struct {
+	sql        strings.Builder
+	args       []any
+	conditions []string
+}
+
+// arg binds v and returns its placeholder.
+func (q *query) arg(v any) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// interval validates an interval such as "30m" or "7d" and binds it in its
+// canonical form.
+func (q *query) interval(s string) (string, error) {
+	d, err := timerange.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidInterval, s)
+	}
+
+	return q.arg(timerange.FormatInterval(d)) + "::interval", nil
+}
+
+func (q *query) write(format string, a ...any) {
+	fmt.Fprintf(&q.sql, format, a...)
+}
+
+// where adds a condition to the next writeWhere.
+func (q *query) where(format string, a ...any) {
+	q.conditions = append(q.conditions, fmt.Sprintf(format, a...))
+}
+
+func (q *query) writeWhere() {
+	if len(q.conditions) == 0 {
+		return
+	}
+
+	q.write(" WHERE %s", strings.Join(q.conditions, " AND "))
+	q.conditions = nil
+}
+
+func (q *query) String() string {
+	return q.sql.String()
+}
+
+func tableIdentifier(table string) (string, error) {
+	if _, ok := tables[table]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownTable, table)
+	}
+
+	return pgx.Identifier{schemaName, table}.Sanitize(), nil
+}
+
+func columnIdentifier(table string, column string) (string, error) {
+	err := ValidateColumn(table, column)
+	if err != nil {
+		return "", err
+	}
+
+	return pgx.Identifier{column}.Sanitize(), nil
+}
+
+// whereDevice restricts multi-device tables to the queried device.
+func (q *query) whereDevice(table string, serialNumber string) error {
+	if !tables[table].serialNumber {
+		return nil
+	}
+
+	if serialNumber == "" {
+		return fmt.Errorf("%w: table %q is shared by several devices", ErrMissingSerialNumber, table)
+	}
+
+	q.where("serial_number = %s", q.arg(serialNumber))
+
+	return nil
+}
+
+// timeBucketGapfill is the bucket expression of a ranged query, returning
+// every bucket from from to to and bucketing on the wall clock of loc when it
+// is set.
+func (q *query) timeBucketGapfill(bucket string, from string, to string, loc *time.Location) string {
+	if loc == nil {
+		return fmt.Sprintf(`time_bucket_gapfill(%s, "time", %s, %s)`, bucket, from, to)
+	}
+
+	return fmt.Sprintf(`time_bucket_gapfill(%s, "time", %s, %s, %s)`, bucket, q.arg(loc.String()), from, to)
+}
+
+// whereRange restricts rows to a lookback window relative to now or, when start
+// is set, to the absolute range [start, end). It returns the bounds of the
+// range as sql expressions.
+func (q *query) whereRange(lookbackInterval string, start time.Time, end time.Time) (string, string, error) {
+	if start.IsZero() {
+		lookback, err := q.interval(lookbackInterval)
+		if err != nil {
+			return "", "", err
+		}
+
+		from := fmt.Sprintf("NOW() - %s", lookback)
+		q.where(`"time" > %s`, from)
+
+		return from, "NOW()", nil
+	}
+
+	from := q.arg(start)
+	to := q.arg(end)
+
+	q.where(`"time" >= %s`, from)
+	q.where(`"time" < %s`, to)
+
+	return from + "::timestamptz", to + "::timestamptz", nil
+}
+
+func buildGetColumnQuery(tp GetColumnTemplateParameters) (*query, error) {
+	table, err := tableIdentifier(tp.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	column, err := columnIdentifier(tp.TableName, tp.ColumnName)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	bucket, err := q.interval(tp.TimeBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := q.whereRange(tp.LookbackInterval, tp.Start, tp.End)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.whereDevice(tp.TableName, tp.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	q.write(`SELECT %s AS "time"`, q.timeBucketGapfill(bucket, from, to, tp.Location))
+
+	err = q.writeBucketAggregates(tp, column)
+	if err != nil {
+		return nil, err
+	}
+
+	q.write(" FROM %s", table)
+	q.writeWhere()
+	q.write(" GROUP BY 1 ORDER BY 1")
+
+	return q, nil
+}
+
+// writeBucketAggregates writes avg, min, max and the requested aggregations
+// of column, filled as requested.
+func (q *query) writeBucketAggregates(tp GetColumnTemplateParameters, column string) error {
+	q.write(", %s, %s, %s",
+		tp.Fill.wrap(fmt.Sprintf("AVG(%s)::float8", column)),
+		tp.Fill.wrap(fmt.Sprintf("MIN(%s)::float8", column)),
+		tp.Fill.wrap(fmt.Sprintf("MAX(%s)::float8", column)))
+
+	for _, a := range tp.Aggregations {
+		expression, err := q.aggregate(a, column)
+		if err != nil {
+			return err
+		}
+
+		if a != AggregateCount {
+			expression = tp.Fill.wrap(expression)
+		}
+		q.write(", %s", expression)
+	}
+
+	return nil
+}
+
+// buildGetExpressionQuery is buildGetColumnQuery for an expression of several
+// columns, computed for each row in which all of them are set.
+func buildGetExpressionQuery(e Expression, tp GetColumnTemplateParameters) (*query, error) {
+	table, err := tableIdentifier(tp.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(e.Columns) == 0 || e.SQL == nil {
+		return nil, fmt.Errorf("%w: %s has no columns", ErrUnknownColumn, e.Name)
+	}
+
+	values := make([]string, 0, len(e.Columns))
+	for _, name := range e.Columns {
+		column, err := columnIdentifier(tp.TableName, name)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, column+"::float8")
+	}
+
+	q := &query{}
+
+	bucket, err := q.interval(tp.TimeBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := q.whereRange(tp.LookbackInterval, tp.Start, tp.End)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.whereDevice(tp.TableName, tp.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range e.Columns {
+		q.where("%s IS NOT NULL", pgx.Identifier{name}.Sanitize())
+	}
+
+	q.write(`SELECT %s AS "time"`, q.timeBucketGapfill(bucket, from, to, tp.Location))
+
+	err = q.writeBucketAggregates(tp, "value")
+	if err != nil {
+		return nil, err
+	}
+
+	q.write(` FROM (SELECT "time", %s AS value FROM %s`, e.SQL(values), table)
+	q.writeWhere()
+	q.write(") AS readings GROUP BY 1 ORDER BY 1")
+
+	return q, nil
+}
+
+// aggregate returns the sql of a bucket aggregation of column, as float8 so
+// every aggregate scans alike. first and last are timescaledb functions.
+func (q *query) aggregate(a BucketAggregation, column string) (string, error) {
+	switch a {
+	case AggregateCount:
+		return fmt.Sprintf("COUNT(%s)::float8", column), nil
+	case AggregateSum:
+		return fmt.Sprintf("SUM(%s)::float8", column), nil
+	case AggregateStddev:
+		return fmt.Sprintf("STDDEV_SAMP(%s)::float8", column), nil
+	case AggregateMedian:
+		return fmt.Sprintf("percentile_cont(0.5) WITHIN GROUP (ORDER BY %s)", column), nil
+	case AggregateFirst:
+		return fmt.Sprintf(`first(%s, "time")::float8`, column), nil
+	case AggregateLast:
+		return fmt.Sprintf(`last(%s, "time")::float8`, column), nil
+	}
+
+	p, ok := a.Percentile()
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidAggregation, a)
+	}
+
+	return fmt.Sprintf("percentile_cont(%s::float8) WITHIN GROUP (ORDER BY %s)", q.arg(p), column), nil
+}
+
+func buildGetColumnLastQuery(tp GetColumnLastTemplateParameters) (*query, error) {
+	table, err := tableIdentifier(tp.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	column, err := columnIdentifier(tp.TableName, tp.ColumnName)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	err = q.whereDevice(tp.TableName, tp.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	q.write(`SELECT "time", %s FROM %s`, column, table)
+	q.writeWhere()
+	q.write(` ORDER BY "time" DESC LIMIT 1`)
+
+	return q, nil
+}
+
+func buildGetBirdnetQuery(tp GetBirdnetTemplateParameters) (*query, error) {
+	table, err := tableIdentifier("birdnet")
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	lookback, err := q.interval(tp.LookbackInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	q.where(`"time" > NOW() - %s`, lookback)
+
+	q.write(`SELECT common_name, count(common_name) AS count FROM %s`, table)
+	q.writeWhere()
+	q.write(" GROUP BY common_name ORDER BY count DESC")
+
+	return q, nil
+}
+
+// columnList validates and quotes several columns of one table.
+func columnList(table string, columns []string) (string, error) {
+	if len(columns) == 0 {
+		return "", fmt.Errorf("%w: no columns selected", ErrUnknownColumn)
+	}
+
+	identifiers := make([]string, 0, len(columns))
+	for _, column := range columns {
+		identifier, err := columnIdentifier(table, column)
+		if err != nil {
+			return "", err
+		}
+		identifiers = append(identifiers, identifier)
+	}
+
+	return strings.Join(identifiers, ", "), nil
+}
+
+func buildGetColumnsQuery(tp GetColumnsTemplateParameters) (*query, error) {
+	table, err := tableIdentifier(tp.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := columnList(tp.TableName, tp.ColumnNames)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	_, _, err = q.whereRange(tp.LookbackInterval, tp.Start, tp.End)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.whereDevice(tp.TableName, tp.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	q.write(`SELECT "time", %s FROM %s`, columns, table)
+	q.writeWhere()
+	q.write(` ORDER BY "time"`)
+
+	return q, nil
+}
+
+func buildGetColumnsLastQuery(tp GetColumnsLastTemplateParameters) (*query, error) {
+	table, err := tableIdentifier(tp.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := columnList(tp.TableName, tp.ColumnNames)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	err = q.whereDevice(tp.TableName, tp.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	q.write(`SELECT "time", %s FROM %s`, columns, table)
+	q.writeWhere()
+	q.write(` ORDER BY "time" DESC LIMIT 1`)
+
+	return q, nil
+}
+
+func buildGetWindDirectionQuery(tp GetColumnTemplateParameters) (*query, error) {
+	table, err := tableIdentifier(windTable)
+	if err != nil {
+		return nil, err
+	}
+
+	direction, err := columnIdentifier(windTable, windDirectionColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	speed, err := columnIdentifier(windTable, windSpeedColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	bucket, err := q.interval(tp.TimeBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := q.whereRange(tp.LookbackInterval, tp.Start, tp.End)
+	if err != nil {
+		return nil, err
+	}
+	q.where("%s IS NOT NULL", direction)
+	q.where("%s IS NOT NULL", speed)
+
+	q.write(`SELECT %s AS "time", `, q.timeBucketGapfill(bucket, from, to, tp.Location))
+	q.write(`AVG(-%[2]s * sin(radians(%[1]s))), AVG(-%[2]s * cos(radians(%[1]s))), `, direction, speed)
+	q.write(`AVG(-sin(radians(%[1]s))), AVG(-cos(radians(%[1]s))), AVG(%[2]s) FROM %[3]s`, direction, speed, table)
+	q.writeWhere()
+	q.write(" GROUP BY 1 ORDER BY 1")
+
+	return q, nil
+}
+
+func buildGetWindRoseQuery(tp GetWindRoseTemplateParameters) (*query, error) {
+	table, err := tableIdentifier(windTable)
+	if err != nil {
+		return nil, err
+	}
+
+	direction, err := columnIdentifier(windTable, windDirectionColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	speed, err := columnIdentifier(windTable, windSpeedColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	lookback, err := q.interval(tp.LookbackInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	q.where(`"time" > NOW() - %s`, lookback)
+	q.where("%s IS NOT NULL", direction)
+	q.where("%s IS NOT NULL", speed)
+
+	sectorWidth := 360.0 / WindRoseSectors
+
+	q.write(`SELECT (floor((%[1]s + %[2]g) / %[3]g)::int %% %[4]d) AS sector, `, direction, sectorWidth/2, sectorWidth, WindRoseSectors)
+	q.write(`width_bucket(%s, %s::float8[]) AS bin, count(*) AS count FROM %s`, speed, q.arg(WindRoseSpeedBins), table)
+	q.writeWhere()
+	q.write(" GROUP BY 1, 2")
+
+	return q, nil
+}
+
+// writeRainIncrements writes a "rain" cte of rain counter increments between
+// from and end. Readings are compared to the previous one of the same local
+// day in tz, read from local midnight on. The first reading of a day, or a
+// drop in the counter, has the counter itself as its increment, so a day's
+// increments add up to the maximum of its counter even across outages.
+func (q *query) writeRainIncrements(from string, end string, tz string) error {
+	table, err := tableIdentifier(rainTable)
+	if err != nil {
+		return err
+	}
+
+	counter, err := columnIdentifier(rainTable, rainCounterColumn)
+	if err != nil {
+		return err
+	}
+
+	q.write(`WITH readings AS (SELECT "time", %[1]s AS counter, %[1]s - LAG(%[1]s) OVER (PARTITION BY date_trunc('day', "time" AT TIME ZONE %[2]s) ORDER BY "time") AS delta FROM %[3]s`, counter, tz, table)
+	q.write(` WHERE "time" >= date_trunc('day', %[1]s::timestamptz AT TIME ZONE %[2]s) AT TIME ZONE %[2]s AND "time" < %[3]s AND %[4]s IS NOT NULL), `, from, tz, end, counter)
+	q.write(`rain AS (SELECT "time", CASE WHEN delta IS NULL OR delta < 0 THEN counter ELSE delta END AS increment FROM readings WHERE "time" >= %s) `, from)
+
+	return nil
+}
+
+func buildGetRainAccumulationQuery(tp GetRainAccumulationTemplateParameters) (*query, error) {
+	if tp.Location == nil {
+		return nil, fmt.Errorf("%w: missing location", ErrInvalidPeriod)
+	}
+
+	q := &query{}
+
+	start := q.arg(tp.Start)
+	end := q.arg(tp.End)
+	tz := q.arg(tp.Location.String())
+
+	err := q.writeRainIncrements(start, end, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tp.Period {
+	case "":
+		q.write(`SELECT %s::timestamptz AT TIME ZONE %s AS period, COALESCE(SUM(increment), 0) FROM rain`, start, tz)
+	case RainPeriodDay, RainPeriodMonth:
+		q.write(`SELECT date_trunc(%s, "time" AT TIME ZONE %s) AS period, COALESCE(SUM(increment), 0) FROM rain GROUP BY 1 ORDER BY 1`, q.arg(string(tp.Period)), tz)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPeriod, tp.Period)
+	}
+
+	return q, nil
+}
+
+func buildGetRainSinceQuery(tp GetRainSinceTemplateParameters) (*query, error) {
+	if tp.Location == nil {
+		return nil, fmt.Errorf("%w: missing location", ErrInvalidPeriod)
+	}
+
+	if len(tp.Since) == 0 {
+		return nil, fmt.Errorf("%w: no start times", ErrInvalidPeriod)
+	}
+
+	earliest := tp.Since[0]
+	for _, t := range tp.Since {
+		if t.Before(earliest) {
+			earliest = t
+		}
+	}
+
+	q := &query{}
+
+	err := q.writeRainIncrements(q.arg(earliest), q.arg(tp.End), q.arg(tp.Location.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make([]string, 0, len(tp.Since))
+	for _, t := range tp.Since {
+		totals = append(totals, fmt.Sprintf(`COALESCE(SUM(increment) FILTER (WHERE "time" >= %s), 0)`, q.arg(t)))
+	}
+
+	q.write("SELECT %s FROM rain", strings.Join(totals, ", "))
+
+	return q, nil
+}
+
+func buildGetClimateDailyQuery(tp GetClimateDailyTemplateParameters) (*query, error) {
+	if tp.Location == nil {
+		return nil, fmt.Errorf("%w: missing location", ErrInvalidPeriod)
+	}
+
+	table, err := tableIdentifier(climateTable)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, 5)
+	for _, column := range []string{climateTemperatureColumn, climateHumidityColumn, climatePressureColumn, climateGustColumn, rainCounterColumn} {
+		identifier, err := columnIdentifier(climateTable, column)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, identifier)
+	}
+
+	q := &query{}
+
+	start := q.arg(tp.Start)
+	end := q.arg(tp.End)
+	tz := q.arg(tp.Location.String())
+
+	// partitioning on null-ness and the local day compares each rain counter
+	// reading to the previous non-null one of the same day, like
+	// writeRainIncrements
+	q.write(`WITH readings AS (SELECT "time", %s AS temperature, %s AS humidity, %s AS pressure, %s AS gust, %[5]s AS counter, %[5]s - LAG(%[5]s) OVER (PARTITION BY %[5]s IS NULL, date_trunc('day', "time" AT TIME ZONE %[6]s) ORDER BY "time") AS delta FROM %[7]s`,
+		columns[0], columns[1], columns[2], columns[3], columns[4], tz, table)
+	q.write(` WHERE "time" >= date_trunc('day', %[1]s::timestamptz AT TIME ZONE %[2]s) AT TIME ZONE %[2]s AND "time" < %[3]s) `, start, tz, end)
+	q.write(`SELECT date_trunc('day', "time" AT TIME ZONE %s) AS day, `, tz)
+	q.write(`MAX(temperature), (array_agg("time" ORDER BY temperature DESC, "time") FILTER (WHERE temperature IS NOT NULL))[1], `)
+	q.write(`MIN(temperature), (array_agg("time" ORDER BY temperature, "time") FILTER (WHERE temperature IS NOT NULL))[1], `)
+	q.write(`MAX(gust), (array_agg("time" ORDER BY gust DESC, "time") FILTER (WHERE gust IS NOT NULL))[1], `)
+	q.write(`COALESCE(SUM(CASE WHEN delta IS NULL OR delta < 0 THEN counter ELSE delta END), 0), AVG(humidity), AVG(pressure) `)
+	q.write(`FROM readings WHERE "time" >= %s GROUP BY 1 ORDER BY 1`, start)
+
+	return q, nil
+}
+
+// whereCalendarDay restricts readings to a local calendar day of every year
+// when both month and day are set.
+func (q *query) whereCalendarDay(tz string, month int, day int) {
+	if month == 0 || day == 0 {
+		return
+	}
+
+	q.where(`EXTRACT(MONTH FROM "time" AT TIME ZONE %[1]s) = %[2]s AND EXTRACT(DAY FROM "time" AT TIME ZONE %[1]s) = %[3]s`, tz, q.arg(month), q.arg(day))
+}
+
+// buildGetDailyWeatherExtremesQuery reads a day's rain as the maximum of the
+// daily rain counter, which only grows until it resets at local midnight.
+func buildGetDailyWeatherExtremesQuery(tp GetDailyExtremesTemplateParameters) (*query, error) {
+	if tp.Location == nil {
+		return nil, fmt.Errorf("%w: missing location", ErrInvalidPeriod)
+	}
+
+	table, err := tableIdentifier(climateTable)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, 3)
+	for _, column := range []string{climateTemperatureColumn, climateGustColumn, rainCounterColumn} {
+		identifier, err := columnIdentifier(climateTable, column)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, identifier)
+	}
+
+	q := &query{}
+
+	tz := q.arg(tp.Location.String())
+
+	q.write(`SELECT date_trunc('day', "time" AT TIME ZONE %s) AS day, `, tz)
+	q.write(`MAX(%[1]s), (array_agg("time" ORDER BY %[1]s DESC, "time") FILTER (WHERE %[1]s IS NOT NULL))[1], `, columns[0])
+	q.write(`MIN(%[1]s), (array_agg("time" ORDER BY %[1]s, "time") FILTER (WHERE %[1]s IS NOT NULL))[1], `, columns[0])
+	q.write(`MAX(%[1]s), (array_agg("time" ORDER BY %[1]s DESC, "time") FILTER (WHERE %[1]s IS NOT NULL))[1], `, columns[1])
+	q.write(`MAX(%s) FROM %s`, columns[2], table)
+	q.whereCalendarDay(tz, tp.Month, tp.Day)
+	q.writeWhere()
+	q.write(" GROUP BY 1 ORDER BY 1")
+
+	return q, nil
+}
+
+func buildGetDailyAQIExtremesQuery(tp GetDailyExtremesTemplateParameters) (*query, error) {
+	if tp.Location == nil {
+		return nil, fmt.Errorf("%w: missing location", ErrInvalidPeriod)
+	}
+
+	table, err := tableIdentifier(aqiTable)
+	if err != nil {
+		return nil, err
+	}
+
+	column, err := columnIdentifier(aqiTable, aqiColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	tz := q.arg(tp.Location.String())
+
+	q.write(`SELECT date_trunc('day', "time" AT TIME ZONE %s) AS day, `, tz)
+	q.write(`MAX(%[1]s), (array_agg("time" ORDER BY %[1]s DESC, "time") FILTER (WHERE %[1]s IS NOT NULL))[1] FROM %[2]s`, column, table)
+
+	err = q.whereDevice(aqiTable, tp.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	q.whereCalendarDay(tz, tp.Month, tp.Day)
+	q.writeWhere()
+	q.write(" GROUP BY 1 ORDER BY 1")
+
+	return q, nil
+}
+
+// buildGetColumnRollupQuery answers a ranged query from a rollup, combining
+// its buckets into the coarser requested bucket.
+func buildGetColumnRollupQuery(r Rollup, tp GetColumnTemplateParameters) (*query, error) {
+	if !r.answers(tp) {
+		return nil, fmt.Errorf("%w: %s cannot answer %s by %s", ErrUnknownColumn, r.View, tp.ColumnName, tp.TimeBucket)
+	}
+
+	// the rollup's columns are named after the allowlisted column
+	_, err := columnIdentifier(tp.TableName, tp.ColumnName)
+	if err != nil {
+		return nil, err
+	}
+
+	count := rollupColumn(tp.ColumnName, "count")
+	sum := rollupColumn(tp.ColumnName, "sum")
+
+	q := &query{}
+
+	bucket, err := q.interval(tp.TimeBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := q.whereRange(tp.LookbackInterval, tp.Start, tp.End)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.whereDevice(tp.TableName, tp.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	q.write(`SELECT %s AS "time", %s, %s, %s`, q.timeBucketGapfill(bucket, from, to, tp.Location),
+		tp.Fill.wrap(fmt.Sprintf("(SUM(%s) / NULLIF(SUM(%s), 0))::float8", sum, count)),
+		tp.Fill.wrap(fmt.Sprintf("MIN(%s)::float8", rollupColumn(tp.ColumnName, "min"))),
+		tp.Fill.wrap(fmt.Sprintf("MAX(%s)::float8", rollupColumn(tp.ColumnName, "max"))))
+
+	for _, a := range tp.Aggregations {
+		switch a {
+		case AggregateCount:
+			q.write(", SUM(%s)::float8", count)
+		case AggregateSum:
+			q.write(", %s", tp.Fill.wrap(fmt.Sprintf("SUM(%s)::float8", sum)))
+		}
+	}
+
+	q.write(" FROM %s", r.identifier())
+	q.writeWhere()
+	q.write(" GROUP BY 1 ORDER BY 1")
+
+	return q, nil
+}
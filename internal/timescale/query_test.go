@@ -0,0 +1,392 @@
+package timescale
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildGetColumnQuery(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		tp           GetColumnTemplateParameters
+		expectedSQL  string
+		expectedArgs []any
+		expectedErr  error
+	}{
+		{
+			name: "Lookback",
+			tp: GetColumnTemplateParameters{
+				ColumnName:       "temperature",
+				TableName:        "vantagepro2plus",
+				TimeBucket:       "30m",
+				LookbackInterval: "12h",
+			},
+			expectedSQL:  `SELECT time_bucket_gapfill($1::interval, "time", NOW() - $2::interval, NOW()) AS "time", AVG("temperature")::float8, MIN("temperature")::float8, MAX("temperature")::float8 FROM "sensors"."vantagepro2plus" WHERE "time" > NOW() - $2::interval GROUP BY 1 ORDER BY 1`,
+			expectedArgs: []any{"30m", "12h"},
+		},
+		{
+			name: "Absolute range on a device table",
+			tp: GetColumnTemplateParameters{
+				ColumnName:   "rco2",
+				TableName:    "airgradient",
+				TimeBucket:   "1d",
+				Start:        start,
+				End:          end,
+				SerialNumber: "84fce6070dd4",
+			},
+			expectedSQL:  `SELECT time_bucket_gapfill($1::interval, "time", $2::timestamptz, $3::timestamptz) AS "time", AVG("rco2")::float8, MIN("rco2")::float8, MAX("rco2")::float8 FROM "sensors"."airgradient" WHERE "time" >= $2 AND "time" < $3 AND serial_number = $4 GROUP BY 1 ORDER BY 1`,
+			expectedArgs: []any{"1d", start, end, "84fce6070dd4"},
+		},
+		{
+			name: "Linear fill",
+			tp: GetColumnTemplateParameters{
+				ColumnName:       "temperature",
+				TableName:        "vantagepro2plus",
+				TimeBucket:       "1h",
+				LookbackInterval: "24h",
+				Aggregations:     []BucketAggregation{AggregateCount, AggregateSum},
+				Fill:             FillLinear,
+			},
+			expectedSQL:  `SELECT time_bucket_gapfill($1::interval, "time", NOW() - $2::interval, NOW()) AS "time", interpolate(AVG("temperature")::float8), interpolate(MIN("temperature")::float8), interpolate(MAX("temperature")::float8), COUNT("temperature")::float8, interpolate(SUM("temperature")::float8) FROM "sensors"."vantagepro2plus" WHERE "time" > NOW() - $2::interval GROUP BY 1 ORDER BY 1`,
+			expectedArgs: []any{"1h", "1d"},
+		},
+		{
+			name: "Local days",
+			tp: GetColumnTemplateParameters{
+				ColumnName:       "temperature",
+				TableName:        "vantagepro2plus",
+				TimeBucket:       "1d",
+				LookbackInterval: "30d",
+				Location:         loc,
+			},
+			expectedSQL:  `SELECT time_bucket_gapfill($1::interval, "time", $3, NOW() - $2::interval, NOW()) AS "time", AVG("temperature")::float8, MIN("temperature")::float8, MAX("temperature")::float8 FROM "sensors"."vantagepro2plus" WHERE "time" > NOW() - $2::interval GROUP BY 1 ORDER BY 1`,
+			expectedArgs: []any{"1d", "30d", "America/Los_Angeles"},
+		},
+		{
+			name: "Device table without a device",
+			tp: GetColumnTemplateParameters{
+				ColumnName:       "rco2",
+				TableName:        "airgradient",
+				TimeBucket:       "1h",
+				LookbackInterval: "24h",
+			},
+			expectedErr: ErrMissingSerialNumber,
+		},
+		{
+			name: "Injected column",
+			tp: GetColumnTemplateParameters{
+				ColumnName:       "temperature); DROP TABLE sensors.vantagepro2plus; --",
+				TableName:        "vantagepro2plus",
+				TimeBucket:       "30m",
+				LookbackInterval: "12h",
+			},
+			expectedErr: ErrUnknownColumn,
+		},
+		{
+			name: "Injected table",
+			tp: GetColumnTemplateParameters{
+				ColumnName:       "temperature",
+				TableName:        `vantagepro2plus" UNION SELECT usename FROM pg_user --`,
+				TimeBucket:       "30m",
+				LookbackInterval: "12h",
+			},
+			expectedErr: ErrUnknownTable,
+		},
+		{
+			name: "Column from another table",
+			tp: GetColumnTemplateParameters{
+				ColumnName:       "aqi",
+				TableName:        "vantagepro2plus",
+				TimeBucket:       "30m",
+				LookbackInterval: "12h",
+			},
+			expectedErr: ErrUnknownColumn,
+		},
+		{
+			name: "Injected bucket",
+			tp: GetColumnTemplateParameters{
+				ColumnName:       "temperature",
+				TableName:        "vantagepro2plus",
+				TimeBucket:       "30m', \"time\"); DROP TABLE x; --",
+				LookbackInterval: "12h",
+			},
+			expectedErr: ErrInvalidInterval,
+		},
+		{
+			name: "Injected lookback",
+			tp: GetColumnTemplateParameters{
+				ColumnName:       "temperature",
+				TableName:        "vantagepro2plus",
+				TimeBucket:       "30m",
+				LookbackInterval: "12h' OR 1=1 --",
+			},
+			expectedErr: ErrInvalidInterval,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := buildGetColumnQuery(tt.tp)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if q.String() != tt.expectedSQL {
+				t.Errorf("expected sql:\n%s\ngot:\n%s", tt.expectedSQL, q.String())
+			}
+
+			if len(q.args) != len(tt.expectedArgs) {
+				t.Fatalf("expected %d args, got %d", len(tt.expectedArgs), len(q.args))
+			}
+			for i := range q.args {
+				if q.args[i] != tt.expectedArgs[i] {
+					t.Errorf("arg %d: expected %v, got %v", i+1, tt.expectedArgs[i], q.args[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildGetColumnLastQuery(t *testing.T) {
+	q, err := buildGetColumnLastQuery(GetColumnLastTemplateParameters{ColumnName: "aqi", TableName: "airgradient_aqi", SerialNumber: "84fce6070dd4"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT "time", "aqi" FROM "sensors"."airgradient_aqi" WHERE serial_number = $1 ORDER BY "time" DESC LIMIT 1`
+	if q.String() != expected {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", expected, q.String())
+	}
+
+	_, err = buildGetColumnLastQuery(GetColumnLastTemplateParameters{ColumnName: "*", TableName: "vantagepro2plus"})
+	if !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected %v, got %v", ErrUnknownColumn, err)
+	}
+}
+
+func TestBuildGetColumnSummaryQuery(t *testing.T) {
+	q, err := buildGetColumnSummaryQuery(GetColumnSummaryTemplateParameters{ColumnName: "rco2", TableName: "airgradient", SerialNumber: "84fce6070dd4", Window: "24h", End: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT AVG("rco2")::float8, MIN("rco2")::float8, MAX("rco2")::float8, COUNT("rco2") FROM "sensors"."airgradient" WHERE "time" > $2::timestamptz - $1::interval AND "time" <= $2 AND serial_number = $3`
+	if q.String() != expected {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", expected, q.String())
+	}
+
+	_, err = buildGetColumnSummaryQuery(GetColumnSummaryTemplateParameters{ColumnName: "temperature", TableName: "vantagepro2plus", Window: "1 day"})
+	if !errors.Is(err, ErrInvalidInterval) {
+		t.Errorf("expected %v, got %v", ErrInvalidInterval, err)
+	}
+}
+
+func TestBuildGetBirdnetQuery(t *testing.T) {
+	_, err := buildGetBirdnetQuery(GetBirdnetTemplateParameters{LookbackInterval: "24 hours'; DELETE FROM sensors.birdnet; --"})
+	if !errors.Is(err, ErrInvalidInterval) {
+		t.Errorf("expected %v, got %v", ErrInvalidInterval, err)
+	}
+
+	q, err := buildGetBirdnetQuery(GetBirdnetTemplateParameters{LookbackInterval: "24h"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT common_name, count(common_name) AS count FROM "sensors"."birdnet" WHERE "time" > NOW() - $1::interval GROUP BY common_name ORDER BY count DESC`
+	if q.String() != expected {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", expected, q.String())
+	}
+}
+
+func TestBuildGetWindRoseQuery(t *testing.T) {
+	q, err := buildGetWindRoseQuery(GetWindRoseTemplateParameters{LookbackInterval: "7d"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT (floor(("wind_direction_last" + 11.25) / 22.5)::int % 16) AS sector, width_bucket("wind_speed_last", $2::float8[]) AS bin, count(*) AS count FROM "sensors"."vantagepro2plus" WHERE "time" > NOW() - $1::interval AND "wind_direction_last" IS NOT NULL AND "wind_speed_last" IS NOT NULL GROUP BY 1, 2`
+	if q.String() != expected {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", expected, q.String())
+	}
+
+	_, err = buildGetWindRoseQuery(GetWindRoseTemplateParameters{LookbackInterval: "1d'; --"})
+	if !errors.Is(err, ErrInvalidInterval) {
+		t.Errorf("expected %v, got %v", ErrInvalidInterval, err)
+	}
+}
+
+func TestBuildGetRainAccumulationQuery(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, loc)
+	end := time.Date(2025, time.April, 1, 0, 0, 0, 0, loc)
+
+	q, err := buildGetRainAccumulationQuery(GetRainAccumulationTemplateParameters{Start: start, End: end, Period: RainPeriodDay, Location: loc})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `WITH readings AS (SELECT "time", "rain_daily" AS counter, "rain_daily" - LAG("rain_daily") OVER (PARTITION BY date_trunc('day', "time" AT TIME ZONE $3) ORDER BY "time") AS delta FROM "sensors"."vantagepro2plus" WHERE "time" >= date_trunc('day', $1::timestamptz AT TIME ZONE $3) AT TIME ZONE $3 AND "time" < $2 AND "rain_daily" IS NOT NULL), rain AS (SELECT "time", CASE WHEN delta IS NULL OR delta < 0 THEN counter ELSE delta END AS increment FROM readings WHERE "time" >= $1) SELECT date_trunc($4, "time" AT TIME ZONE $3) AS period, COALESCE(SUM(increment), 0) FROM rain GROUP BY 1 ORDER BY 1`
+	if q.String() != expected {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", expected, q.String())
+	}
+
+	if q.args[2] != "America/Los_Angeles" {
+		t.Errorf("expected the time zone to be bound, got %v", q.args[2])
+	}
+
+	_, err = buildGetRainAccumulationQuery(GetRainAccumulationTemplateParameters{Start: start, End: end, Period: "day'); --", Location: loc})
+	if !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("expected %v, got %v", ErrInvalidPeriod, err)
+	}
+}
+
+func TestBuildGetRainSinceQuery(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	now := time.Date(2025, time.March, 15, 12, 0, 0, 0, loc)
+	day := time.Date(2025, time.March, 15, 0, 0, 0, 0, loc)
+	month := time.Date(2025, time.March, 1, 0, 0, 0, 0, loc)
+
+	q, err := buildGetRainSinceQuery(GetRainSinceTemplateParameters{Since: []time.Time{day, month}, End: now, Location: loc})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `WITH readings AS (SELECT "time", "rain_daily" AS counter, "rain_daily" - LAG("rain_daily") OVER (PARTITION BY date_trunc('day', "time" AT TIME ZONE $3) ORDER BY "time") AS delta FROM "sensors"."vantagepro2plus" WHERE "time" >= date_trunc('day', $1::timestamptz AT TIME ZONE $3) AT TIME ZONE $3 AND "time" < $2 AND "rain_daily" IS NOT NULL), rain AS (SELECT "time", CASE WHEN delta IS NULL OR delta < 0 THEN counter ELSE delta END AS increment FROM readings WHERE "time" >= $1) SELECT COALESCE(SUM(increment) FILTER (WHERE "time" >= $4), 0), COALESCE(SUM(increment) FILTER (WHERE "time" >= $5), 0) FROM rain`
+	if q.String() != expected {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", expected, q.String())
+	}
+
+	if q.args[0] != month {
+		t.Errorf("expected increments from the earliest start, got %v", q.args[0])
+	}
+
+	_, err = buildGetRainSinceQuery(GetRainSinceTemplateParameters{Since: []time.Time{day}, End: now})
+	if !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("expected %v, got %v", ErrInvalidPeriod, err)
+	}
+}
+
+func TestBuildGetClimateDailyQuery(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, loc)
+	end := time.Date(2025, time.April, 1, 0, 0, 0, 0, loc)
+
+	q, err := buildGetClimateDailyQuery(GetClimateDailyTemplateParameters{Start: start, End: end, Location: loc})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []any{start, end, "America/Los_Angeles"}
+	if len(q.args) != len(expected) {
+		t.Fatalf("expected %d args, got %d", len(expected), len(q.args))
+	}
+	for i := range expected {
+		if q.args[i] != expected[i] {
+			t.Errorf("expected arg %d to be %v, got %v", i+1, expected[i], q.args[i])
+		}
+	}
+
+	if !strings.Contains(q.String(), `PARTITION BY "rain_daily" IS NULL, date_trunc('day', "time" AT TIME ZONE $3)`) {
+		t.Errorf("expected rain to be compared within a local day, got:\n%s", q.String())
+	}
+
+	_, err = buildGetClimateDailyQuery(GetClimateDailyTemplateParameters{Start: start, End: end})
+	if !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("expected %v, got %v", ErrInvalidPeriod, err)
+	}
+}
+
+func TestBuildGetColumnQueryAggregations(t *testing.T) {
+	q, err := buildGetColumnQuery(GetColumnTemplateParameters{
+		ColumnName:       "temperature",
+		TimeBucket:       "1h",
+		LookbackInterval: "24h",
+		TableName:        "vantagepro2plus",
+		Aggregations:     []BucketAggregation{AggregateCount, AggregateFirst, "p95"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT time_bucket_gapfill($1::interval, "time", NOW() - $2::interval, NOW()) AS "time", AVG("temperature")::float8, MIN("temperature")::float8, MAX("temperature")::float8, COUNT("temperature")::float8, first("temperature", "time")::float8, percentile_cont($3::float8) WITHIN GROUP (ORDER BY "temperature") FROM "sensors"."vantagepro2plus" WHERE "time" > NOW() - $2::interval GROUP BY 1 ORDER BY 1`
+	if q.String() != expected {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", expected, q.String())
+	}
+
+	if q.args[2] != 0.95 {
+		t.Errorf("expected the percentile to be bound, got %v", q.args[2])
+	}
+
+	_, err = buildGetColumnQuery(GetColumnTemplateParameters{
+		ColumnName:       "temperature",
+		TimeBucket:       "1h",
+		LookbackInterval: "24h",
+		TableName:        "vantagepro2plus",
+		Aggregations:     []BucketAggregation{"mode"},
+	})
+	if !errors.Is(err, ErrInvalidAggregation) {
+		t.Errorf("expected %v, got %v", ErrInvalidAggregation, err)
+	}
+}
+
+func TestBuildGetExpressionQuery(t *testing.T) {
+	sum := Expression{
+		Name:    "sum",
+		Columns: []string{"temperature", "humidity"},
+		SQL:     func(c []string) string { return c[0] + " + " + c[1] },
+	}
+
+	q, err := buildGetExpressionQuery(sum, GetColumnTemplateParameters{
+		TimeBucket:       "1h",
+		LookbackInterval: "24h",
+		TableName:        "vantagepro2plus",
+		Aggregations:     []BucketAggregation{AggregateLast},
+		Fill:             FillLOCF,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT time_bucket_gapfill($1::interval, "time", NOW() - $2::interval, NOW()) AS "time", locf(AVG(value)::float8), locf(MIN(value)::float8), locf(MAX(value)::float8), locf(last(value, "time")::float8) FROM (SELECT "time", "temperature"::float8 + "humidity"::float8 AS value FROM "sensors"."vantagepro2plus" WHERE "time" > NOW() - $2::interval AND "temperature" IS NOT NULL AND "humidity" IS NOT NULL) AS readings GROUP BY 1 ORDER BY 1`
+	if q.String() != expected {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", expected, q.String())
+	}
+
+	sum.Columns = []string{"temperature", "password"}
+	_, err = buildGetExpressionQuery(sum, GetColumnTemplateParameters{
+		TimeBucket:       "1h",
+		LookbackInterval: "24h",
+		TableName:        "vantagepro2plus",
+	})
+	if !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("expected %v, got %v", ErrUnknownColumn, err)
+	}
+}
@@ -0,0 +1,210 @@
+package timescale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	rainTable = "vantagepro2plus"
+	// rainCounterColumn is the console's daily rain counter, which only grows
+	// during a day and resets at local midnight. Totals are summed from its
+	// increments rather than from rain rates.
+	rainCounterColumn = "rain_daily"
+)
+
+// RainPeriod is a calendar period rain totals are grouped by.
+type RainPeriod string
+
+const (
+	RainPeriodDay   RainPeriod = "day"
+	RainPeriodMonth RainPeriod = "month"
+)
+
+// GetRainAccumulationTemplateParameters totals rain over [Start, End) per
+// Period in Location, or as a single total when Period is empty. A Relative
+// range, resolved against now, is cached to the minute.
+type GetRainAccumulationTemplateParameters struct {
+	Start    time.Time
+	End      time.Time
+	Period   RainPeriod
+	Location *time.Location
+	Relative bool
+}
+
+func (t *GetRainAccumulationTemplateParameters) String() string {
+	return fmt.Sprintf("rain-%s-%s-%s",
+		rangeKey(t.Start, t.End, t.Relative, timerange.MinBucket),
+		t.Period,
+		strings.ReplaceAll(t.Location.String(), " ", ""))
+}
+
+func (t *GetRainAccumulationTemplateParameters) Hash() string {
+	return strconv.FormatUint(xxhash.Sum64String(t.String()), 16)
+}
+
+// GetRainAccumulationResponse is the rain total of the period starting at
+// Start, in the local time of the request.
+type GetRainAccumulationResponse struct {
+	Start time.Time `json:"start"`
+	Total float64   `json:"total"`
+	Unit  string    `json:"unit,omitempty"`
+}
+
+// GetRainAccumulation returns one total per period, or a single total for the
+// whole range when no period is set.
+func (c *TimescaleClient) GetRainAccumulation(ctx context.Context, tp GetRainAccumulationTemplateParameters) ([]GetRainAccumulationResponse, error) {
+	if c.Dfly != nil {
+		res, err := c.Dfly.GetClient().Get(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash())).Result()
+		if err == nil {
+			var getRainAccumulationResponses []GetRainAccumulationResponse
+			err := json.Unmarshal([]byte(res), &getRainAccumulationResponses)
+			if err != nil {
+				slog.Error("failed to unmarshal from dragonfly", slog.String("error", err.Error()))
+			}
+			return getRainAccumulationResponses, nil
+		} else if !errors.Is(err, redis.Nil) {
+			slog.Error("failed to get from dragonfly", slog.String("error", err.Error()))
+		}
+	}
+
+	query, err := buildGetRainAccumulationQuery(tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	rows, err := c.Pool.Query(ctx, query.String(), query.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rain accumulation from %s to %s: %w", tp.Start.Format(time.RFC3339), tp.End.Format(time.RFC3339), err)
+	}
+	defer rows.Close()
+
+	var getRainAccumulationResponses []GetRainAccumulationResponse
+
+	for rows.Next() {
+		var (
+			row   GetRainAccumulationResponse
+			local time.Time
+		)
+		err := rows.Scan(&local, &row.Total)
+		if err != nil {
+			slog.Error("failed to scan row", slog.String("error", err.Error()))
+			continue
+		}
+
+		row.Start = wallClock(local, tp.Location)
+
+		getRainAccumulationResponses = append(getRainAccumulationResponses, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rain accumulation from %s to %s: %w", tp.Start.Format(time.RFC3339), tp.End.Format(time.RFC3339), err)
+	}
+
+	if c.Dfly != nil {
+		getRainAccumulationResponsesJSON, err := json.Marshal(getRainAccumulationResponses)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := c.Dfly.GetClient().Set(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash()), getRainAccumulationResponsesJSON, c.Dfly.CacheResultsDuration).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return getRainAccumulationResponses, nil
+}
+
+// GetRainSinceTemplateParameters totals rain from each of Since up to End,
+// with days in Location. End is usually now, so it is cached to the minute.
+type GetRainSinceTemplateParameters struct {
+	Since    []time.Time
+	End      time.Time
+	Location *time.Location
+}
+
+func (t *GetRainSinceTemplateParameters) String() string {
+	since := make([]string, len(t.Since))
+	for i, s := range t.Since {
+		since[i] = strconv.FormatInt(s.UnixNano(), 10)
+	}
+
+	return fmt.Sprintf("rainsince-%s-%d-%s",
+		strings.Join(since, "_"),
+		t.End.Truncate(timerange.MinBucket).UnixNano(),
+		strings.ReplaceAll(t.Location.String(), " ", ""))
+}
+
+func (t *GetRainSinceTemplateParameters) Hash() string {
+	return strconv.FormatUint(xxhash.Sum64String(t.String()), 16)
+}
+
+// GetRainSince totals rain from each of Since up to End in a single query.
+func (c *TimescaleClient) GetRainSince(ctx context.Context, tp GetRainSinceTemplateParameters) ([]float64, error) {
+	if c.Dfly != nil {
+		res, err := c.Dfly.GetClient().Get(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash())).Result()
+		if err == nil {
+			var totals []float64
+			err := json.Unmarshal([]byte(res), &totals)
+			if err != nil {
+				slog.Error("failed to unmarshal from dragonfly", slog.String("error", err.Error()))
+			}
+			if len(totals) == len(tp.Since) {
+				return totals, nil
+			}
+		} else if !errors.Is(err, redis.Nil) {
+			slog.Error("failed to get from dragonfly", slog.String("error", err.Error()))
+		}
+	}
+
+	query, err := buildGetRainSinceQuery(tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	totals := make([]float64, len(tp.Since))
+	dest := make([]any, len(tp.Since))
+	for i := range totals {
+		dest[i] = &totals[i]
+	}
+
+	err = c.Pool.QueryRow(ctx, query.String(), query.args...).Scan(dest...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rain totals: %w", err)
+	}
+
+	if c.Dfly != nil {
+		totalsJSON, err := json.Marshal(totals)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := c.Dfly.GetClient().Set(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash()), totalsJSON, c.Dfly.CacheResultsDuration).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return totals, nil
+}
+
+// wallClock reinterprets a timestamp without time zone, which pgx scans as
+// UTC, as a wall clock time in loc.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
@@ -0,0 +1,289 @@
+package timescale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
+)
+
+// undefinedTable is the sqlstate of a query on a relation that does not exist.
+const undefinedTable = "42P01"
+
+// Rollup is a continuous aggregate of a table, with the count, sum, min and max
+// of each of Columns per Width bucket. Ranged queries with buckets that are a
+// multiple of Width are answered from it instead of the raw readings.
+type Rollup struct {
+	View    string
+	Table   string
+	Width   time.Duration
+	Columns []string
+	// Local rollups are bucketed by the wall clock of the station's zone, see
+	// RollupsIn, so that daily buckets start at local midnight.
+	Local bool
+	// Location is the zone a Local rollup is bucketed in, nil for utc.
+	Location *time.Location
+	// refresh policy, see add_continuous_aggregate_policy
+	StartOffset      time.Duration
+	EndOffset        time.Duration
+	ScheduleInterval time.Duration
+}
+
+var (
+	vantagepro2plusRollupColumns = []string{
+		"temperature",
+		"humidity",
+		"barometer_sea_level",
+		"solar_radiation",
+		"wind_speed_last",
+		"wind_speed_high_last_10_min",
+		"rain_rate_last",
+		"rain_last_24_hour",
+		"uv_index",
+	}
+	airgradientRollupColumns = []string{
+		"rco2",
+		"nox_index",
+		"tvoc_index",
+	}
+)
+
+var Rollups = []Rollup{
+	{
+		View:             "vantagepro2plus_hourly",
+		Table:            "vantagepro2plus",
+		Width:            time.Hour,
+		Columns:          vantagepro2plusRollupColumns,
+		StartOffset:      3 * time.Hour,
+		EndOffset:        time.Hour,
+		ScheduleInterval: 30 * time.Minute,
+	},
+	{
+		View:             "vantagepro2plus_daily",
+		Table:            "vantagepro2plus",
+		Width:            24 * time.Hour,
+		Columns:          vantagepro2plusRollupColumns,
+		Local:            true,
+		StartOffset:      3 * 24 * time.Hour,
+		EndOffset:        24 * time.Hour,
+		ScheduleInterval: time.Hour,
+	},
+	{
+		View:             "airgradient_hourly",
+		Table:            "airgradient",
+		Width:            time.Hour,
+		Columns:          airgradientRollupColumns,
+		StartOffset:      3 * time.Hour,
+		EndOffset:        time.Hour,
+		ScheduleInterval: 30 * time.Minute,
+	},
+	{
+		View:             "airgradient_daily",
+		Table:            "airgradient",
+		Width:            24 * time.Hour,
+		Columns:          airgradientRollupColumns,
+		Local:            true,
+		StartOffset:      3 * 24 * time.Hour,
+		EndOffset:        24 * time.Hour,
+		ScheduleInterval: time.Hour,
+	},
+}
+
+// RollupsIn returns Rollups with the Local rollups bucketed in loc, nil for
+// utc. Views created in one zone only answer queries in that zone, so they are
+// dropped and created again when the station's zone changes.
+func RollupsIn(loc *time.Location) []Rollup {
+	rollups := slices.Clone(Rollups)
+	for i := range rollups {
+		if rollups[i].Local {
+			rollups[i].Location = loc
+		}
+	}
+
+	return rollups
+}
+
+// rollupAggregations are the bucket aggregations a rollup can answer besides
+// avg, min and max.
+var rollupAggregations = []BucketAggregation{AggregateCount, AggregateSum}
+
+func (r Rollup) identifier() string {
+	return pgx.Identifier{schemaName, r.View}.Sanitize()
+}
+
+func rollupColumn(column string, aggregate string) string {
+	return pgx.Identifier{column + "_" + aggregate}.Sanitize()
+}
+
+// CreateStatements returns the statements creating the continuous aggregate
+// and its refresh policy. The view is created without data, see
+// RefreshStatement. Real time aggregation is enabled so the latest, not yet
+// materialized, buckets are still answered from the raw readings.
+func (r Rollup) CreateStatements() ([]string, error) {
+	table, err := tableIdentifier(r.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	// the view is ddl, so the zone is a literal rather than a bind parameter
+	bucket := fmt.Sprintf(`time_bucket(INTERVAL '%s', "time")`, timerange.FormatInterval(r.Width))
+	if r.Location != nil {
+		bucket = fmt.Sprintf(`time_bucket(INTERVAL '%s', "time", '%s')`, timerange.FormatInterval(r.Width), strings.ReplaceAll(r.Location.String(), "'", "''"))
+	}
+
+	selects := []string{bucket + ` AS "time"`}
+	groups := []string{"1"}
+
+	if tables[r.Table].serialNumber {
+		selects = append(selects, "serial_number")
+		groups = append(groups, "serial_number")
+	}
+
+	for _, column := range r.Columns {
+		identifier, err := columnIdentifier(r.Table, column)
+		if err != nil {
+			return nil, err
+		}
+
+		selects = append(selects,
+			fmt.Sprintf("COUNT(%s) AS %s", identifier, rollupColumn(column, "count")),
+			fmt.Sprintf("SUM(%s) AS %s", identifier, rollupColumn(column, "sum")),
+			fmt.Sprintf("MIN(%s) AS %s", identifier, rollupColumn(column, "min")),
+			fmt.Sprintf("MAX(%s) AS %s", identifier, rollupColumn(column, "max")),
+		)
+	}
+
+	return []string{
+		fmt.Sprintf(`CREATE MATERIALIZED VIEW IF NOT EXISTS %s WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS SELECT %s FROM %s GROUP BY %s WITH NO DATA`,
+			r.identifier(), strings.Join(selects, ", "), table, strings.Join(groups, ", ")),
+		fmt.Sprintf(`SELECT add_continuous_aggregate_policy('%s.%s', start_offset => INTERVAL '%s', end_offset => INTERVAL '%s', schedule_interval => INTERVAL '%s', if_not_exists => true)`,
+			schemaName, r.View, timerange.FormatInterval(r.StartOffset), timerange.FormatInterval(r.EndOffset), timerange.FormatInterval(r.ScheduleInterval)),
+	}, nil
+}
+
+// RefreshStatement materializes the whole history of the view.
+func (r Rollup) RefreshStatement() string {
+	return fmt.Sprintf(`CALL refresh_continuous_aggregate('%s.%s', NULL, NULL)`, schemaName, r.View)
+}
+
+// DropStatement drops the view and its refresh policy.
+func (r Rollup) DropStatement() string {
+	return fmt.Sprintf(`DROP MATERIALIZED VIEW IF EXISTS %s`, r.identifier())
+}
+
+// answers reports whether the rollup can answer a ranged query.
+func (r Rollup) answers(tp GetColumnTemplateParameters) bool {
+	if r.Table != tp.TableName || !slices.Contains(r.Columns, tp.ColumnName) {
+		return false
+	}
+
+	for _, a := range tp.Aggregations {
+		if !slices.Contains(rollupAggregations, a) {
+			return false
+		}
+	}
+
+	bucket, err := timerange.ParseDuration(tp.TimeBucket)
+	if err != nil {
+		return false
+	}
+
+	return bucket >= r.Width && bucket%r.Width == 0 && r.alignedIn(tp)
+}
+
+// alignedIn reports whether the rollup's buckets line up with the wall clock
+// of the query's location. Buckets of a rollup with a Location only line up in
+// that zone, utc buckets wherever the utc offset is a multiple of the rollup
+// width at both ends of the range.
+func (r Rollup) alignedIn(tp GetColumnTemplateParameters) bool {
+	if r.Location != nil {
+		loc := tp.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+
+		return loc.String() == r.Location.String()
+	}
+
+	if tp.Location == nil {
+		return true
+	}
+
+	start, end, err := tp.Range(time.Now())
+	if err != nil {
+		return false
+	}
+
+	for _, t := range []time.Time{start, end} {
+		_, offset := t.In(tp.Location).Zone()
+		if (time.Duration(offset)*time.Second)%r.Width != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rollupFor returns the coarsest of rollups that can answer a ranged query.
+func rollupFor(rollups []Rollup, tp GetColumnTemplateParameters) (Rollup, bool) {
+	var (
+		best  Rollup
+		found bool
+	)
+
+	for _, r := range rollups {
+		if r.answers(tp) && (!found || r.Width > best.Width) {
+			best, found = r, true
+		}
+	}
+
+	return best, found
+}
+
+// ManageRollups runs the statements of action, one of create, refresh or
+// drop, for every rollup in the client's rollup location.
+func (c *TimescaleClient) ManageRollups(ctx context.Context, action string) error {
+	for _, r := range RollupsIn(c.RollupLocation) {
+		var statements []string
+
+		switch action {
+		case "create":
+			created, err := r.CreateStatements()
+			if err != nil {
+				return fmt.Errorf("failed to build %s: %w", r.View, err)
+			}
+			statements = created
+		case "refresh":
+			statements = []string{r.RefreshStatement()}
+		case "drop":
+			statements = []string{r.DropStatement()}
+		default:
+			return fmt.Errorf("unknown rollup action %q", action)
+		}
+
+		for _, statement := range statements {
+			slog.Debug("statement", slog.String("statement", statement))
+
+			_, err := c.Pool.Exec(ctx, statement)
+			if err != nil {
+				return fmt.Errorf("failed to %s %s: %w", action, r.View, err)
+			}
+		}
+
+		slog.Info("managed rollup", slog.String("action", action), slog.String("view", r.View))
+	}
+
+	return nil
+}
+
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == undefinedTable
+}
@@ -0,0 +1,112 @@
+package timescale
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRollupFor(t *testing.T) {
+	pacific, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	india, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		rollups      []Rollup
+		tp           GetColumnTemplateParameters
+		expectedView string
+	}{
+		{name: "Fine bucket", tp: GetColumnTemplateParameters{ColumnName: "temperature", TimeBucket: "30m", TableName: "vantagepro2plus"}},
+		{name: "Hourly", tp: GetColumnTemplateParameters{ColumnName: "temperature", TimeBucket: "6h", TableName: "vantagepro2plus"}, expectedView: "vantagepro2plus_hourly"},
+		{name: "Daily", tp: GetColumnTemplateParameters{ColumnName: "rco2", TimeBucket: "7d", TableName: "airgradient"}, expectedView: "airgradient_daily"},
+		{name: "Unaligned bucket", tp: GetColumnTemplateParameters{ColumnName: "temperature", TimeBucket: "90m", TableName: "vantagepro2plus"}},
+		{name: "Count and sum", tp: GetColumnTemplateParameters{ColumnName: "rain_rate_last", TimeBucket: "1d", TableName: "vantagepro2plus", Aggregations: []BucketAggregation{AggregateSum, AggregateCount}}, expectedView: "vantagepro2plus_daily"},
+		{name: "Percentile", tp: GetColumnTemplateParameters{ColumnName: "temperature", TimeBucket: "1d", TableName: "vantagepro2plus", Aggregations: []BucketAggregation{"p95"}}},
+		{name: "Local days", tp: GetColumnTemplateParameters{ColumnName: "temperature", TimeBucket: "1d", LookbackInterval: "30d", TableName: "vantagepro2plus", Location: pacific}, expectedView: "vantagepro2plus_hourly"},
+		{name: "Station days", rollups: RollupsIn(pacific), tp: GetColumnTemplateParameters{ColumnName: "temperature", TimeBucket: "1d", LookbackInterval: "30d", TableName: "vantagepro2plus", Location: pacific}, expectedView: "vantagepro2plus_daily"},
+		{name: "Utc days of a station", rollups: RollupsIn(pacific), tp: GetColumnTemplateParameters{ColumnName: "temperature", TimeBucket: "1d", TableName: "vantagepro2plus"}, expectedView: "vantagepro2plus_hourly"},
+		{name: "Half hour offset", tp: GetColumnTemplateParameters{ColumnName: "temperature", TimeBucket: "1d", LookbackInterval: "30d", TableName: "vantagepro2plus", Location: india}},
+		{name: "Column without rollup", tp: GetColumnTemplateParameters{ColumnName: "aqi", TimeBucket: "1d", TableName: "airgradient_aqi"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollups := tt.rollups
+			if rollups == nil {
+				rollups = Rollups
+			}
+
+			rollup, ok := rollupFor(rollups, tt.tp)
+			if tt.expectedView == "" {
+				if ok {
+					t.Errorf("expected no rollup, got %s", rollup.View)
+				}
+				return
+			}
+			if !ok || rollup.View != tt.expectedView {
+				t.Errorf("expected %s, got %s", tt.expectedView, rollup.View)
+			}
+		})
+	}
+}
+
+func TestBuildGetColumnRollupQuery(t *testing.T) {
+	tp := GetColumnTemplateParameters{
+		ColumnName:       "rco2",
+		TimeBucket:       "1d",
+		LookbackInterval: "30d",
+		TableName:        "airgradient",
+		SerialNumber:     "84fce6070dd4",
+		Aggregations:     []BucketAggregation{AggregateCount},
+	}
+
+	rollup, ok := rollupFor(Rollups, tp)
+	if !ok {
+		t.Fatal("expected a rollup")
+	}
+
+	q, err := buildGetColumnRollupQuery(rollup, tp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT time_bucket_gapfill($1::interval, "time", NOW() - $2::interval, NOW()) AS "time", (SUM("rco2_sum") / NULLIF(SUM("rco2_count"), 0))::float8, MIN("rco2_min")::float8, MAX("rco2_max")::float8, SUM("rco2_count")::float8 FROM "sensors"."airgradient_daily" WHERE "time" > NOW() - $2::interval AND serial_number = $3 GROUP BY 1 ORDER BY 1`
+	if q.String() != expected {
+		t.Errorf("expected sql:\n%s\ngot:\n%s", expected, q.String())
+	}
+}
+
+func TestRollupCreateStatements(t *testing.T) {
+	pacific, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	for _, rollup := range RollupsIn(pacific) {
+		statements, err := rollup.CreateStatements()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", rollup.View, err)
+		}
+
+		if !strings.Contains(statements[0], "timescaledb.continuous") {
+			t.Errorf("%s: expected a continuous aggregate, got %s", rollup.View, statements[0])
+		}
+
+		zoned := strings.Contains(statements[0], `"time", 'America/Los_Angeles')`)
+		if zoned != rollup.Local {
+			t.Errorf("%s: expected bucketing in the station's zone to be %t, got %s", rollup.View, rollup.Local, statements[0])
+		}
+
+		grouped := strings.Contains(statements[0], "GROUP BY 1, serial_number")
+		if grouped != tables[rollup.Table].serialNumber {
+			t.Errorf("%s: expected grouping by serial number to be %t", rollup.View, tables[rollup.Table].serialNumber)
+		}
+	}
+}
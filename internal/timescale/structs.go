@@ -2,14 +2,81 @@ package timescale
 
 import "time"
 
+// GetColumnResponse is one bucket of a ranged query. Min, Max and Avg are nil
+// for buckets without readings unless they are filled. The aggregates after
+// Avg are only set when requested, and are nil when they are undefined for the
+// bucket, e.g. the stddev of a single reading.
 type GetColumnResponse struct {
-	Time time.Time `json:"time"`
-	Min  float64   `json:"min"`
-	Max  float64   `json:"max"`
-	Avg  float64   `json:"avg"`
+	Time        time.Time           `json:"time"`
+	Min         *float64            `json:"min"`
+	Max         *float64            `json:"max"`
+	Avg         *float64            `json:"avg"`
+	Count       *int64              `json:"count,omitempty"`
+	Sum         *float64            `json:"sum,omitempty"`
+	Stddev      *float64            `json:"stddev,omitempty"`
+	Median      *float64            `json:"median,omitempty"`
+	First       *float64            `json:"first,omitempty"`
+	Last        *float64            `json:"last,omitempty"`
+	Percentiles map[string]*float64 `json:"percentiles,omitempty"`
+	Unit        string              `json:"unit,omitempty"`
 }
 
+// SetAggregate sets the value of aggregation a.
+func (r *GetColumnResponse) SetAggregate(a BucketAggregation, v *float64) {
+	switch a {
+	case AggregateCount:
+		if v != nil {
+			count := int64(*v)
+			r.Count = &count
+		}
+	case AggregateSum:
+		r.Sum = v
+	case AggregateStddev:
+		r.Stddev = v
+	case AggregateMedian:
+		r.Median = v
+	case AggregateFirst:
+		r.First = v
+	case AggregateLast:
+		r.Last = v
+	default:
+		if r.Percentiles == nil {
+			r.Percentiles = map[string]*float64{}
+		}
+		r.Percentiles[string(a)] = v
+	}
+}
+
+// GetColumnLastResponse is the latest reading. AgeSeconds and Stale are set
+// by the handler when the response is served, they are not cached.
 type GetColumnLastResponse struct {
-	Time time.Time `json:"time"`
-	Last float64   `json:"last"`
+	Time       time.Time `json:"time"`
+	Last       float64   `json:"last"`
+	Unit       string    `json:"unit,omitempty"`
+	AgeSeconds int64     `json:"age_seconds"`
+	Stale      bool      `json:"stale"`
+}
+
+// Aggregate returns the value of aggregation a.
+func (r *GetColumnResponse) Aggregate(a BucketAggregation) *float64 {
+	switch a {
+	case AggregateCount:
+		if r.Count == nil {
+			return nil
+		}
+		count := float64(*r.Count)
+		return &count
+	case AggregateSum:
+		return r.Sum
+	case AggregateStddev:
+		return r.Stddev
+	case AggregateMedian:
+		return r.Median
+	case AggregateFirst:
+		return r.First
+	case AggregateLast:
+		return r.Last
+	default:
+		return r.Percentiles[string(a)]
+	}
 }
@@ -0,0 +1,129 @@
+package timescale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// summaryCacheDuration caps how long a summary is cached. Summaries end at a
+// reading and are only asked for until the next one arrives, so keeping each
+// for the full cache duration would only fill the cache.
+const summaryCacheDuration = time.Minute
+
+// GetColumnSummaryTemplateParameters selects the readings of a rolling window
+// ending at End, i.e. (End - Window, End]. Since the window is fixed by End
+// rather than by now, subscribers to the same reading share a cached summary.
+type GetColumnSummaryTemplateParameters struct {
+	ColumnName   string
+	TableName    string
+	SerialNumber string
+	Window       string
+	End          time.Time
+}
+
+func (t *GetColumnSummaryTemplateParameters) String() string {
+	return fmt.Sprintf("summary-%s-%s-%d-%s-%s",
+		strings.ReplaceAll(t.ColumnName, " ", ""),
+		strings.ReplaceAll(t.Window, " ", ""),
+		t.End.UnixNano(),
+		strings.ReplaceAll(t.TableName, " ", ""),
+		strings.ReplaceAll(t.SerialNumber, " ", ""))
+}
+
+func (t *GetColumnSummaryTemplateParameters) Hash() string {
+	return strconv.FormatUint(xxhash.Sum64String(t.String()), 16)
+}
+
+// GetColumnSummary returns the avg, min, max and count of a column over a
+// rolling window as a single bucket at its end. Avg, Min and Max are nil when
+// the window has no readings.
+func (c *TimescaleClient) GetColumnSummary(ctx context.Context, tp GetColumnSummaryTemplateParameters) (*GetColumnResponse, error) {
+	if c.Dfly != nil {
+		res, err := c.Dfly.GetClient().Get(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash())).Result()
+		if err == nil {
+			var getColumnSummaryResponse GetColumnResponse
+			err := json.Unmarshal([]byte(res), &getColumnSummaryResponse)
+			if err != nil {
+				slog.Error("failed to unmarshal from dragonfly", slog.String("error", err.Error()))
+			}
+			return &getColumnSummaryResponse, nil
+		} else if !errors.Is(err, redis.Nil) {
+			slog.Error("failed to get from dragonfly", slog.String("error", err.Error()))
+		}
+	}
+
+	query, err := buildGetColumnSummaryQuery(tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	row := c.Pool.QueryRow(ctx, query.String(), query.args...)
+
+	getColumnSummaryResponse := GetColumnResponse{Time: tp.End}
+	var count int64
+
+	err = row.Scan(&getColumnSummaryResponse.Avg, &getColumnSummaryResponse.Min, &getColumnSummaryResponse.Max, &count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize %s: %w", tp.ColumnName, err)
+	}
+	getColumnSummaryResponse.Count = &count
+
+	if c.Dfly != nil {
+		getColumnSummaryResponseJSON, err := json.Marshal(getColumnSummaryResponse)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := c.Dfly.GetClient().Set(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash()), getColumnSummaryResponseJSON, min(c.Dfly.CacheResultsDuration, summaryCacheDuration)).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return &getColumnSummaryResponse, nil
+}
+
+func buildGetColumnSummaryQuery(tp GetColumnSummaryTemplateParameters) (*query, error) {
+	table, err := tableIdentifier(tp.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	column, err := columnIdentifier(tp.TableName, tp.ColumnName)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &query{}
+
+	window, err := q.interval(tp.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	end := q.arg(tp.End)
+
+	q.where(`"time" > %s::timestamptz - %s`, end, window)
+	q.where(`"time" <= %s`, end)
+
+	err = q.whereDevice(tp.TableName, tp.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	q.write(`SELECT AVG(%[1]s)::float8, MIN(%[1]s)::float8, MAX(%[1]s)::float8, COUNT(%[1]s) FROM %[2]s`, column, table)
+	q.writeWhere()
+
+	return q, nil
+}
@@ -1,7 +1,6 @@
 package timescale
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,56 +8,125 @@ import (
 	"log/slog"
 	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
-	_ "embed"
-
 	"github.com/cespare/xxhash/v2"
 	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/michaelpeterswa/lfpweather-api/internal/dragonfly"
+	"github.com/michaelpeterswa/lfpweather-api/internal/timerange"
 	"github.com/redis/go-redis/v9"
 )
 
 type TimescaleClient struct {
-	Pool                  *pgxpool.Pool
-	Dfly                  *dragonfly.DragonflyClient
-	getColumnTemplate     *template.Template
-	getColumnLastTemplate *template.Template
-	getBirdnetTemplate    *template.Template
+	Pool *pgxpool.Pool
+	Dfly *dragonfly.DragonflyClient
+	// Rollups enables answering coarse ranged queries from Rollups.
+	Rollups bool
+	// RollupLocation is the zone Local rollups are bucketed in, nil for utc.
+	RollupLocation *time.Location
 }
 
-//go:embed queries/getcolumn.pgsql.gotmpl
-var getColumnTemplate string
-
-//go:embed queries/getcolumnlast.pgsql.gotmpl
-var getColumnLastTemplate string
-
+// GetColumnTemplateParameters selects either a lookback window relative to
+// now (LookbackInterval) or, when Start is set, the absolute range [Start, End).
+// SerialNumber is required for tables shared by several devices.
 type GetColumnTemplateParameters struct {
 	ColumnName       string
 	TimeBucket       string
 	LookbackInterval string
+	Start            time.Time
+	End              time.Time
 	TableName        string
+	SerialNumber     string
+	// Aggregations are computed per bucket in addition to avg, min and max.
+	Aggregations []BucketAggregation
+	// Fill fills the buckets without readings, which are null by default.
+	Fill Fill
+	// Location aligns buckets to its wall clock, e.g. daily buckets to local
+	// midnight, instead of to utc.
+	Location *time.Location
+	// Relative marks a range resolved against now. Its cache key is rounded
+	// down to the bucket width so repeated requests share an entry.
+	Relative bool
 }
 
 func (t *GetColumnTemplateParameters) String() string {
-	return fmt.Sprintf("%s-%s-%s-%s",
+	lookback := strings.ReplaceAll(t.LookbackInterval, " ", "")
+	if !t.Start.IsZero() {
+		granularity, _ := timerange.ParseDuration(t.TimeBucket)
+		lookback = rangeKey(t.Start, t.End, t.Relative, granularity)
+	}
+
+	s := fmt.Sprintf("%s-%s-%s-%s-%s",
 		strings.ReplaceAll(t.ColumnName, " ", ""),
 		strings.ReplaceAll(t.TimeBucket, " ", ""),
-		strings.ReplaceAll(t.LookbackInterval, " ", ""),
-		strings.ReplaceAll(t.TableName, " ", ""))
+		lookback,
+		strings.ReplaceAll(t.TableName, " ", ""),
+		strings.ReplaceAll(t.SerialNumber, " ", ""))
+
+	for _, a := range t.Aggregations {
+		s += "-" + string(a)
+	}
+
+	if t.Fill != "" && t.Fill != FillNull {
+		s += "-fill_" + string(t.Fill)
+	}
+
+	if t.Location != nil {
+		s += "-tz_" + t.Location.String()
+	}
+
+	return s
+}
+
+// rangeKey keys the range [start, end) of a cache entry. A relative range
+// differs on every request, so it is rounded down to granularity first;
+// entries still expire after the cache duration, which bounds how stale a
+// shared entry can be.
+func rangeKey(start time.Time, end time.Time, relative bool, granularity time.Duration) string {
+	if relative && granularity > 0 {
+		start = start.Truncate(granularity)
+		end = end.Truncate(granularity)
+	}
+
+	return fmt.Sprintf("%d_%d", start.UnixNano(), end.UnixNano())
+}
+
+// Range returns the absolute range selected, resolving a lookback relative to
+// now.
+func (t *GetColumnTemplateParameters) Range(now time.Time) (time.Time, time.Time, error) {
+	if !t.Start.IsZero() {
+		return t.Start, t.End, nil
+	}
+
+	lookback, err := timerange.ParseDuration(t.LookbackInterval)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return now.Add(-lookback), now, nil
+}
+
+func (t *GetColumnTemplateParameters) describeRange() string {
+	if t.Start.IsZero() {
+		return fmt.Sprintf("the last %s", t.LookbackInterval)
+	}
+
+	return fmt.Sprintf("%s to %s", t.Start.Format(time.RFC3339), t.End.Format(time.RFC3339))
 }
 
 type GetColumnLastTemplateParameters struct {
-	ColumnName string
-	TableName  string
+	ColumnName   string
+	TableName    string
+	SerialNumber string
 }
 
 func (t *GetColumnLastTemplateParameters) String() string {
-	return fmt.Sprintf("%s-%s",
+	return fmt.Sprintf("%s-%s-%s",
 		strings.ReplaceAll(t.ColumnName, " ", ""),
-		strings.ReplaceAll(t.TableName, " ", ""))
+		strings.ReplaceAll(t.TableName, " ", ""),
+		strings.ReplaceAll(t.SerialNumber, " ", ""))
 }
 
 func (t *GetColumnTemplateParameters) Hash() string {
@@ -77,23 +145,20 @@ func WithDragonflyClient(dfly *dragonfly.DragonflyClient) TimescaleClientOption
 	}
 }
 
-func NewTimescaleClient(ctx context.Context, connString string, opts ...TimescaleClientOption) (*TimescaleClient, error) {
-	timescaleClient := &TimescaleClient{}
-
-	getColumnTmpl, err := template.New("getColumn").Parse(getColumnTemplate)
-	if err != nil {
-		return nil, fmt.Errorf("parse getColumn template: %w", err)
+func WithRollups(enabled bool) TimescaleClientOption {
+	return func(c *TimescaleClient) {
+		c.Rollups = enabled
 	}
+}
 
-	getColumnLastTmpl, err := template.New("getColumnLast").Parse(getColumnLastTemplate)
-	if err != nil {
-		return nil, fmt.Errorf("parse getColumnLast template: %w", err)
+func WithRollupLocation(loc *time.Location) TimescaleClientOption {
+	return func(c *TimescaleClient) {
+		c.RollupLocation = loc
 	}
+}
 
-	getBirdnetTemplate, err := template.New("getBirdnet").Parse(getBirdnetTemplate)
-	if err != nil {
-		return nil, fmt.Errorf("parse getBirdnet template: %w", err)
-	}
+func NewTimescaleClient(ctx context.Context, connString string, opts ...TimescaleClientOption) (*TimescaleClient, error) {
+	timescaleClient := &TimescaleClient{}
 
 	cfg, err := pgxpool.ParseConfig(connString)
 	if err != nil {
@@ -120,9 +185,6 @@ func NewTimescaleClient(ctx context.Context, connString string, opts ...Timescal
 	}
 
 	timescaleClient.Pool = pool
-	timescaleClient.getColumnTemplate = getColumnTmpl
-	timescaleClient.getColumnLastTemplate = getColumnLastTmpl
-	timescaleClient.getBirdnetTemplate = getBirdnetTemplate
 
 	return timescaleClient, nil
 }
@@ -146,46 +208,142 @@ func (c *TimescaleClient) GetColumn(ctx context.Context, tp GetColumnTemplatePar
 		}
 	}
 
-	query := bytes.NewBuffer(nil)
-	err := c.getColumnTemplate.Execute(query, tp)
+	var getColumnResponses []GetColumnResponse
+
+	err := c.StreamColumn(ctx, tp, func(row GetColumnResponse) error {
+		getColumnResponses = append(getColumnResponses, row)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query template: %w", err)
+		return nil, err
+	}
+
+	if c.Dfly != nil {
+		getColumnResponsesJSON, err := json.Marshal(getColumnResponses)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := c.Dfly.GetClient().Set(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash()), getColumnResponsesJSON, c.Dfly.CacheResultsDuration).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return getColumnResponses, nil
+}
+
+// StreamColumn runs a ranged query without the cache, calling fn with each
+// bucket as it is read so large ranges are never held in memory. It stops at
+// the first error fn returns.
+func (c *TimescaleClient) StreamColumn(ctx context.Context, tp GetColumnTemplateParameters, fn func(GetColumnResponse) error) error {
+	rows, err := c.queryColumn(ctx, tp)
+	if err != nil {
+		return fmt.Errorf("failed to get %s for %s: %w", tp.ColumnName, tp.describeRange(), err)
+	}
+	defer rows.Close()
+
+	err = scanBuckets(rows, tp.Aggregations, fn)
+	if err != nil {
+		return err
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("failed to read %s for %s: %w", tp.ColumnName, tp.describeRange(), err)
+	}
+
+	return nil
+}
+
+// StreamExpression is StreamColumn for an expression of several columns of
+// tp.TableName, bucketed in the database. tp.ColumnName is ignored, and
+// rollups are never used since they cannot answer an expression.
+func (c *TimescaleClient) StreamExpression(ctx context.Context, e Expression, tp GetColumnTemplateParameters, fn func(GetColumnResponse) error) error {
+	query, err := buildGetExpressionQuery(e, tp)
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
 	}
 
 	slog.Debug("query", slog.String("query", query.String()))
 
-	rows, err := c.Pool.Query(ctx, query.String())
+	rows, err := c.Pool.Query(ctx, query.String(), query.args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get %s for the last %s: %w", tp.ColumnName, tp.LookbackInterval, err)
+		return fmt.Errorf("failed to get %s for %s: %w", e.Name, tp.describeRange(), err)
 	}
 	defer rows.Close()
 
-	var getColumnResponses []GetColumnResponse
+	err = scanBuckets(rows, tp.Aggregations, fn)
+	if err != nil {
+		return err
+	}
 
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("failed to read %s for %s: %w", e.Name, tp.describeRange(), err)
+	}
+
+	return nil
+}
+
+// scanBuckets calls fn with each bucket of a ranged query, stopping at the
+// first error fn returns. Callers check rows.Err afterwards.
+func scanBuckets(rows pgx.Rows, aggregations []BucketAggregation, fn func(GetColumnResponse) error) error {
 	for rows.Next() {
 		var row GetColumnResponse
-		err := rows.Scan(&row.Time, &row.Avg, &row.Min, &row.Max)
+
+		aggregates := make([]*float64, len(aggregations))
+		dest := []any{&row.Time, &row.Avg, &row.Min, &row.Max}
+		for i := range aggregates {
+			dest = append(dest, &aggregates[i])
+		}
+
+		err := rows.Scan(dest...)
 		if err != nil {
 			slog.Error("failed to scan row", slog.String("error", err.Error()))
 			continue
 		}
 
-		getColumnResponses = append(getColumnResponses, row)
+		for i, a := range aggregations {
+			row.SetAggregate(a, aggregates[i])
+		}
+
+		err = fn(row)
+		if err != nil {
+			return err
+		}
 	}
 
-	if c.Dfly != nil {
-		getColumnResponsesJSON, err := json.Marshal(getColumnResponses)
+	return nil
+}
+
+// queryColumn runs a ranged query against a rollup when one can answer it,
+// falling back to the raw readings when the rollup has not been created.
+func (c *TimescaleClient) queryColumn(ctx context.Context, tp GetColumnTemplateParameters) (pgx.Rows, error) {
+	if rollup, ok := rollupFor(RollupsIn(c.RollupLocation), tp); c.Rollups && ok {
+		query, err := buildGetColumnRollupQuery(rollup, tp)
 		if err != nil {
-			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
-		} else {
-			err := c.Dfly.GetClient().Set(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash()), getColumnResponsesJSON, c.Dfly.CacheResultsDuration).Err()
-			if err != nil {
-				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
-			}
+			return nil, fmt.Errorf("failed to build query: %w", err)
 		}
+
+		slog.Debug("query", slog.String("query", query.String()))
+
+		rows, err := c.Pool.Query(ctx, query.String(), query.args...)
+		if err == nil || !isUndefinedTable(err) {
+			return rows, err
+		}
+
+		slog.Warn("rollup is missing, falling back to raw readings", slog.String("view", rollup.View))
 	}
 
-	return getColumnResponses, nil
+	query, err := buildGetColumnQuery(tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	return c.Pool.Query(ctx, query.String(), query.args...)
 }
 
 func (c *TimescaleClient) GetColumnLast(ctx context.Context, tp GetColumnLastTemplateParameters) (*GetColumnLastResponse, error) {
@@ -203,15 +361,14 @@ func (c *TimescaleClient) GetColumnLast(ctx context.Context, tp GetColumnLastTem
 		}
 	}
 
-	query := bytes.NewBuffer(nil)
-	err := c.getColumnLastTemplate.Execute(query, tp)
+	query, err := buildGetColumnLastQuery(tp)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query template: %w", err)
+		return nil, fmt.Errorf("failed to build query: %w", err)
 	}
 
 	slog.Debug("query", slog.String("query", query.String()))
 
-	row := c.Pool.QueryRow(ctx, query.String())
+	row := c.Pool.QueryRow(ctx, query.String(), query.args...)
 
 	var getColumnLastResponse GetColumnLastResponse
 
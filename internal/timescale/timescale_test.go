@@ -0,0 +1,88 @@
+package timescale_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/timescale"
+)
+
+func TestHashIncludesDevice(t *testing.T) {
+	indoor := timescale.GetColumnTemplateParameters{ColumnName: "rco2", TableName: "airgradient", TimeBucket: "1h", LookbackInterval: "24h", SerialNumber: "indoor"}
+	outdoor := indoor
+	outdoor.SerialNumber = "outdoor"
+
+	if indoor.Hash() == outdoor.Hash() {
+		t.Error("expected different devices to hash differently")
+	}
+
+	indoorLast := timescale.GetColumnLastTemplateParameters{ColumnName: "rco2", TableName: "airgradient", SerialNumber: "indoor"}
+	outdoorLast := indoorLast
+	outdoorLast.SerialNumber = "outdoor"
+
+	if indoorLast.Hash() == outdoorLast.Hash() {
+		t.Error("expected different devices to hash differently")
+	}
+}
+
+func TestHashRoundsRelativeRanges(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 5, 0, time.UTC)
+
+	first := timescale.GetColumnTemplateParameters{ColumnName: "temperature", TableName: "vantagepro2plus", TimeBucket: "1h", Start: now.Add(-24 * time.Hour), End: now, Relative: true}
+	second := first
+	second.Start = first.Start.Add(10 * time.Second)
+	second.End = first.End.Add(10 * time.Second)
+
+	if first.Hash() != second.Hash() {
+		t.Error("expected relative ranges within one bucket to hash the same")
+	}
+
+	second.Start = first.Start.Add(time.Hour)
+	second.End = first.End.Add(time.Hour)
+
+	if first.Hash() == second.Hash() {
+		t.Error("expected relative ranges a bucket apart to hash differently")
+	}
+
+	absolute := first
+	absolute.Relative = false
+	absoluteLater := second
+	absoluteLater.Start = first.Start.Add(10 * time.Second)
+	absoluteLater.End = first.End.Add(10 * time.Second)
+	absoluteLater.Relative = false
+
+	if absolute.Hash() == absoluteLater.Hash() {
+		t.Error("expected absolute ranges to hash exactly")
+	}
+}
+
+func TestColumnsHashRoundsRelativeRanges(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 5, 0, time.UTC)
+
+	first := timescale.GetColumnsTemplateParameters{ColumnNames: []string{"temperature", "humidity"}, TableName: "vantagepro2plus", Start: now.Add(-24 * time.Hour), End: now, Relative: true}
+	second := first
+	second.Start = first.Start.Add(30 * time.Second)
+	second.End = first.End.Add(30 * time.Second)
+
+	if first.Hash() != second.Hash() {
+		t.Error("expected relative ranges within one minute to hash the same")
+	}
+
+	second.End = first.End.Add(time.Minute)
+
+	if first.Hash() == second.Hash() {
+		t.Error("expected relative ranges a minute apart to hash differently")
+	}
+}
+
+func TestRainHashRoundsRelativeRanges(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 5, 0, time.UTC)
+
+	first := timescale.GetRainAccumulationTemplateParameters{Start: now.Add(-24 * time.Hour), End: now, Period: timescale.RainPeriodDay, Location: time.UTC, Relative: true}
+	second := first
+	second.End = first.End.Add(30 * time.Second)
+
+	if first.Hash() != second.Hash() {
+		t.Error("expected relative ranges within one minute to hash the same")
+	}
+}
@@ -0,0 +1,299 @@
+package timescale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	windTable           = "vantagepro2plus"
+	windDirectionColumn = "wind_direction_last"
+	windSpeedColumn     = "wind_speed_last"
+
+	// WindRoseSectors is the number of compass sectors in a wind rose.
+	WindRoseSectors = 16
+)
+
+// WindRoseSpeedBins are the lower edges, in mph, of the wind rose speed bins.
+// Readings below the first edge are calm and have no meaningful direction.
+var WindRoseSpeedBins = []float64{1, 4, 8, 13, 19, 25}
+
+var compassPoints = [WindRoseSectors]string{
+	"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+}
+
+// GetWindDirectionResponse is a vector averaged wind direction bucket.
+// Direction is where the wind blows from in degrees, Speed is the magnitude of
+// the mean wind vector and Steadiness is that magnitude over the mean scalar
+// speed, 1 for a constant direction and near 0 for variable winds. All three
+// are null for buckets without readings.
+type GetWindDirectionResponse struct {
+	Time       time.Time `json:"time"`
+	Direction  *float64  `json:"direction"`
+	Speed      *float64  `json:"speed"`
+	Steadiness *float64  `json:"steadiness"`
+	Unit       string    `json:"unit,omitempty"`
+	SpeedUnit  string    `json:"speed_unit,omitempty"`
+}
+
+type GetWindRoseTemplateParameters struct {
+	LookbackInterval string
+}
+
+func (t *GetWindRoseTemplateParameters) String() string {
+	return fmt.Sprintf("windrose-%s", strings.ReplaceAll(t.LookbackInterval, " ", ""))
+}
+
+func (t *GetWindRoseTemplateParameters) Hash() string {
+	return strconv.FormatUint(xxhash.Sum64String(t.String()), 16)
+}
+
+type WindRoseSector struct {
+	Direction   string    `json:"direction"`
+	Degrees     float64   `json:"degrees"`
+	Counts      []int     `json:"counts"`
+	Frequencies []float64 `json:"frequencies"`
+}
+
+// GetWindRoseResponse counts readings by compass sector and speed bin.
+// Counts and Frequencies of each sector are indexed like SpeedBins, and
+// frequencies are fractions of Total, calm readings included.
+type GetWindRoseResponse struct {
+	Total         int              `json:"total"`
+	Calm          int              `json:"calm"`
+	CalmFrequency float64          `json:"calm_frequency"`
+	SpeedBins     []float64        `json:"speed_bins"`
+	Sectors       []WindRoseSector `json:"sectors"`
+	SpeedUnit     string           `json:"speed_unit,omitempty"`
+}
+
+func windDirectionCacheKey(prefix string, hash string) string {
+	return fmt.Sprintf("%s-winddirection-%s", prefix, hash)
+}
+
+// GetWindDirection vector averages the wind per bucket. Each reading is split
+// into u/v components weighted by its speed; when every reading in a bucket is
+// calm the unweighted unit vectors are used instead. Every bucket of the range
+// is returned, like GetColumn.
+func (c *TimescaleClient) GetWindDirection(ctx context.Context, tp GetColumnTemplateParameters) ([]GetWindDirectionResponse, error) {
+	if c.Dfly != nil {
+		res, err := c.Dfly.GetClient().Get(ctx, windDirectionCacheKey(c.Dfly.KeyPrefix, tp.Hash())).Result()
+		if err == nil {
+			var getWindDirectionResponses []GetWindDirectionResponse
+			err := json.Unmarshal([]byte(res), &getWindDirectionResponses)
+			if err != nil {
+				slog.Error("failed to unmarshal from dragonfly", slog.String("error", err.Error()))
+			}
+			return getWindDirectionResponses, nil
+		} else if !errors.Is(err, redis.Nil) {
+			slog.Error("failed to get from dragonfly", slog.String("error", err.Error()))
+		}
+	}
+
+	query, err := buildGetWindDirectionQuery(tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	rows, err := c.Pool.Query(ctx, query.String(), query.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wind direction for %s: %w", tp.describeRange(), err)
+	}
+	defer rows.Close()
+
+	var getWindDirectionResponses []GetWindDirectionResponse
+
+	for rows.Next() {
+		var (
+			bucket                  time.Time
+			u, v, unitU, unitV, avg *float64
+		)
+		err := rows.Scan(&bucket, &u, &v, &unitU, &unitV, &avg)
+		if err != nil {
+			slog.Error("failed to scan row", slog.String("error", err.Error()))
+			continue
+		}
+
+		getWindDirectionResponses = append(getWindDirectionResponses, windDirectionBucket(bucket, u, v, unitU, unitV, avg))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wind direction for %s: %w", tp.describeRange(), err)
+	}
+
+	if c.Dfly != nil {
+		getWindDirectionResponsesJSON, err := json.Marshal(getWindDirectionResponses)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := c.Dfly.GetClient().Set(ctx, windDirectionCacheKey(c.Dfly.KeyPrefix, tp.Hash()), getWindDirectionResponsesJSON, c.Dfly.CacheResultsDuration).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return getWindDirectionResponses, nil
+}
+
+// windDirectionBucket computes a bucket from its mean wind vector, mean unit
+// vector and mean scalar speed, which are all null when it has no readings.
+func windDirectionBucket(bucket time.Time, u, v, unitU, unitV, avg *float64) GetWindDirectionResponse {
+	response := GetWindDirectionResponse{Time: bucket}
+	if u == nil || v == nil || unitU == nil || unitV == nil || avg == nil {
+		return response
+	}
+
+	speed := math.Hypot(*u, *v)
+	response.Speed = &speed
+
+	var direction, steadiness float64
+	if speed > 0 {
+		direction = vectorDirection(*u, *v)
+		steadiness = speed / *avg
+	} else {
+		direction = vectorDirection(*unitU, *unitV)
+	}
+	response.Direction = &direction
+	response.Steadiness = &steadiness
+
+	return response
+}
+
+// vectorDirection converts mean u/v components, which point where the wind
+// blows to, back into the meteorological direction the wind blows from.
+func vectorDirection(u float64, v float64) float64 {
+	direction := math.Mod(math.Atan2(-u, -v)*180/math.Pi+360, 360)
+
+	// atan2 rounding can produce 360 for due north
+	if direction >= 359.9995 {
+		return 0
+	}
+
+	return direction
+}
+
+// GetWindRose counts readings by sector and speed bin over the lookback interval.
+func (c *TimescaleClient) GetWindRose(ctx context.Context, tp GetWindRoseTemplateParameters) (*GetWindRoseResponse, error) {
+	if c.Dfly != nil {
+		res, err := c.Dfly.GetClient().Get(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash())).Result()
+		if err == nil {
+			var getWindRoseResponse GetWindRoseResponse
+			err := json.Unmarshal([]byte(res), &getWindRoseResponse)
+			if err != nil {
+				slog.Error("failed to unmarshal from dragonfly", slog.String("error", err.Error()))
+			}
+			return &getWindRoseResponse, nil
+		} else if !errors.Is(err, redis.Nil) {
+			slog.Error("failed to get from dragonfly", slog.String("error", err.Error()))
+		}
+	}
+
+	query, err := buildGetWindRoseQuery(tp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	slog.Debug("query", slog.String("query", query.String()))
+
+	rows, err := c.Pool.Query(ctx, query.String(), query.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wind rose for the last %s: %w", tp.LookbackInterval, err)
+	}
+	defer rows.Close()
+
+	getWindRoseResponse := newWindRose()
+
+	for rows.Next() {
+		var sector, bin, count int
+		err := rows.Scan(&sector, &bin, &count)
+		if err != nil {
+			slog.Error("failed to scan row", slog.String("error", err.Error()))
+			continue
+		}
+
+		getWindRoseResponse.add(sector, bin, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wind rose for the last %s: %w", tp.LookbackInterval, err)
+	}
+
+	getWindRoseResponse.computeFrequencies()
+
+	if c.Dfly != nil {
+		getWindRoseResponseJSON, err := json.Marshal(getWindRoseResponse)
+		if err != nil {
+			slog.Error("failed to marshal to dragonfly", slog.String("error", err.Error()))
+		} else {
+			err := c.Dfly.GetClient().Set(ctx, fmt.Sprintf("%s-%s", c.Dfly.KeyPrefix, tp.Hash()), getWindRoseResponseJSON, c.Dfly.CacheResultsDuration).Err()
+			if err != nil {
+				slog.Error("failed to set to dragonfly", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	return getWindRoseResponse, nil
+}
+
+func newWindRose() *GetWindRoseResponse {
+	rose := &GetWindRoseResponse{
+		SpeedBins: append([]float64(nil), WindRoseSpeedBins...),
+		Sectors:   make([]WindRoseSector, WindRoseSectors),
+	}
+
+	for i := range rose.Sectors {
+		rose.Sectors[i] = WindRoseSector{
+			Direction:   compassPoints[i],
+			Degrees:     float64(i) * 360 / WindRoseSectors,
+			Counts:      make([]int, len(WindRoseSpeedBins)),
+			Frequencies: make([]float64, len(WindRoseSpeedBins)),
+		}
+	}
+
+	return rose
+}
+
+// add records count readings in a sector and a width_bucket speed bin, where
+// bin 0 is calm and bins 1..n map to SpeedBins.
+func (rose *GetWindRoseResponse) add(sector int, bin int, count int) {
+	rose.Total += count
+
+	if bin == 0 {
+		rose.Calm += count
+		return
+	}
+
+	if sector < 0 || sector >= len(rose.Sectors) || bin > len(rose.SpeedBins) {
+		slog.Warn("wind rose cell out of range", slog.Int("sector", sector), slog.Int("bin", bin))
+		return
+	}
+
+	rose.Sectors[sector].Counts[bin-1] += count
+}
+
+func (rose *GetWindRoseResponse) computeFrequencies() {
+	if rose.Total == 0 {
+		return
+	}
+
+	rose.CalmFrequency = float64(rose.Calm) / float64(rose.Total)
+	for i := range rose.Sectors {
+		for j, count := range rose.Sectors[i].Counts {
+			rose.Sectors[i].Frequencies[j] = float64(count) / float64(rose.Total)
+		}
+	}
+}
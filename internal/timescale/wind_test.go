@@ -0,0 +1,68 @@
+package timescale
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestVectorDirection(t *testing.T) {
+	// mean of a 10 mph wind from 350° and a 10 mph wind from 10°
+	var u, v float64
+	for _, d := range []float64{350, 10} {
+		u += -10 * math.Sin(d*math.Pi/180) / 2
+		v += -10 * math.Cos(d*math.Pi/180) / 2
+	}
+
+	if direction := vectorDirection(u, v); direction != 0 {
+		t.Errorf("expected 0, got %f", direction)
+	}
+
+	tests := map[float64]float64{0: 0, 90: 90, 180: 180, 270: 270}
+	for from, expected := range tests {
+		u := -math.Sin(from * math.Pi / 180)
+		v := -math.Cos(from * math.Pi / 180)
+		if direction := vectorDirection(u, v); math.Abs(direction-expected) > 0.001 {
+			t.Errorf("from %f: expected %f, got %f", from, expected, direction)
+		}
+	}
+}
+
+func TestWindDirectionBucket(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	empty := windDirectionBucket(now, nil, nil, nil, nil, nil)
+	if empty.Direction != nil || empty.Speed != nil || empty.Steadiness != nil {
+		t.Errorf("expected an empty bucket to be null, got %+v", empty)
+	}
+
+	// calm readings from due east, whose unit vector points west
+	zero, unitU := 0.0, -1.0
+	calm := windDirectionBucket(now, &zero, &zero, &unitU, &zero, &zero)
+	if calm.Direction == nil || math.Abs(*calm.Direction-90) > 0.001 {
+		t.Errorf("expected calm readings to keep their direction, got %v", calm.Direction)
+	}
+	if calm.Speed == nil || *calm.Speed != 0 || calm.Steadiness == nil || *calm.Steadiness != 0 {
+		t.Errorf("expected a calm bucket to have no speed or steadiness, got %+v", calm)
+	}
+}
+
+func TestWindRose(t *testing.T) {
+	rose := newWindRose()
+	rose.add(0, 0, 2)
+	rose.add(0, 1, 4)
+	rose.add(4, 3, 2)
+	rose.computeFrequencies()
+
+	if rose.Total != 8 || rose.Calm != 2 || rose.CalmFrequency != 0.25 {
+		t.Errorf("unexpected totals %d/%d/%f", rose.Total, rose.Calm, rose.CalmFrequency)
+	}
+
+	if rose.Sectors[0].Direction != "N" || rose.Sectors[0].Frequencies[0] != 0.5 {
+		t.Errorf("unexpected north sector %+v", rose.Sectors[0])
+	}
+
+	if rose.Sectors[4].Direction != "E" || rose.Sectors[4].Degrees != 90 || rose.Sectors[4].Counts[2] != 2 {
+		t.Errorf("unexpected east sector %+v", rose.Sectors[4])
+	}
+}
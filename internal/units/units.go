@@ -0,0 +1,133 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownSystem = errors.New("unknown unit system")
+
+// System is a unit system requested with ?units=.
+type System string
+
+const (
+	Imperial System = "imperial"
+	Metric   System = "metric"
+	// SI uses base units where they are practical. Rainfall stays in millimetres,
+	// as metres would make every reading a tiny fraction.
+	SI System = "si"
+)
+
+var Systems = []System{Imperial, Metric, SI}
+
+// ParseSystem parses a unit system, defaulting to Imperial, the unit system the
+// station reports in.
+func ParseSystem(s string) (System, error) {
+	switch System(strings.ToLower(strings.TrimSpace(s))) {
+	case "", Imperial:
+		return Imperial, nil
+	case Metric:
+		return Metric, nil
+	case SI:
+		return SI, nil
+	default:
+		return "", fmt.Errorf("%w: %s (expected one of imperial, metric, si)", ErrUnknownSystem, s)
+	}
+}
+
+// Quantity is a physical quantity whose unit depends on the unit system.
+// Readings are always stored in imperial units.
+type Quantity string
+
+const (
+	// None is a quantity whose unit does not change between systems.
+	None              Quantity = ""
+	Temperature       Quantity = "temperature"
+	Pressure          Quantity = "pressure"
+	Speed             Quantity = "speed"
+	Precipitation     Quantity = "precipitation"
+	PrecipitationRate Quantity = "precipitation_rate"
+)
+
+type conversion struct {
+	symbol  string
+	convert func(float64) float64
+}
+
+func identity(v float64) float64 {
+	return v
+}
+
+func scale(factor float64) func(float64) float64 {
+	return func(v float64) float64 {
+		return v * factor
+	}
+}
+
+var conversions = map[Quantity]map[System]conversion{
+	Temperature: {
+		Imperial: {symbol: "°F", convert: identity},
+		Metric:   {symbol: "°C", convert: fahrenheitToCelsius},
+		SI:       {symbol: "K", convert: func(v float64) float64 { return fahrenheitToCelsius(v) + 273.15 }},
+	},
+	Pressure: {
+		Imperial: {symbol: "inHg", convert: identity},
+		Metric:   {symbol: "hPa", convert: scale(33.8638866667)},
+		SI:       {symbol: "Pa", convert: scale(3386.38866667)},
+	},
+	Speed: {
+		Imperial: {symbol: "mph", convert: identity},
+		Metric:   {symbol: "km/h", convert: scale(1.609344)},
+		SI:       {symbol: "m/s", convert: scale(0.44704)},
+	},
+	Precipitation: {
+		Imperial: {symbol: "in", convert: identity},
+		Metric:   {symbol: "mm", convert: scale(25.4)},
+		SI:       {symbol: "mm", convert: scale(25.4)},
+	},
+	PrecipitationRate: {
+		Imperial: {symbol: "in/h", convert: identity},
+		Metric:   {symbol: "mm/h", convert: scale(25.4)},
+		SI:       {symbol: "mm/h", convert: scale(25.4)},
+	},
+}
+
+func fahrenheitToCelsius(v float64) float64 {
+	return (v - 32) * 5 / 9
+}
+
+// Convertible reports whether q has different units between systems.
+func (q Quantity) Convertible() bool {
+	_, ok := conversions[q]
+	return ok
+}
+
+// Symbol is the unit symbol of q in system s, or "" for quantities that are
+// not convertible.
+func (q Quantity) Symbol(s System) string {
+	return conversions[q][s].symbol
+}
+
+// Convert converts an imperial reading of q into system s.
+func (q Quantity) Convert(s System, v float64) float64 {
+	c, ok := conversions[q][s]
+	if !ok {
+		return v
+	}
+
+	return c.convert(v)
+}
+
+// ConvertDifference converts a difference between two imperial readings of q,
+// such as a standard deviation, into system s. Unlike Convert it ignores the
+// offset between temperature scales.
+func (q Quantity) ConvertDifference(s System, v float64) float64 {
+	return q.Convert(s, v) - q.Convert(s, 0)
+}
+
+// ConvertSum converts the sum of n imperial readings of q into the sum of the
+// same readings converted into system s.
+func (q Quantity) ConvertSum(s System, sum float64, n int64) float64 {
+	return q.ConvertDifference(s, sum) + float64(n)*q.Convert(s, 0)
+}
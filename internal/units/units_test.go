@@ -0,0 +1,77 @@
+package units_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/michaelpeterswa/lfpweather-api/internal/units"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name           string
+		quantity       units.Quantity
+		system         units.System
+		input          float64
+		expected       float64
+		expectedSymbol string
+	}{
+		{name: "Freezing in metric", quantity: units.Temperature, system: units.Metric, input: 32, expected: 0, expectedSymbol: "°C"},
+		{name: "Boiling in si", quantity: units.Temperature, system: units.SI, input: 212, expected: 373.15, expectedSymbol: "K"},
+		{name: "Imperial is unchanged", quantity: units.Temperature, system: units.Imperial, input: 54.3, expected: 54.3, expectedSymbol: "°F"},
+		{name: "Standard pressure in metric", quantity: units.Pressure, system: units.Metric, input: 29.92, expected: 1013.21, expectedSymbol: "hPa"},
+		{name: "Standard pressure in si", quantity: units.Pressure, system: units.SI, input: 29.92, expected: 101320.75, expectedSymbol: "Pa"},
+		{name: "Wind in metric", quantity: units.Speed, system: units.Metric, input: 10, expected: 16.09344, expectedSymbol: "km/h"},
+		{name: "Wind in si", quantity: units.Speed, system: units.SI, input: 10, expected: 4.4704, expectedSymbol: "m/s"},
+		{name: "Rain in metric", quantity: units.Precipitation, system: units.Metric, input: 1, expected: 25.4, expectedSymbol: "mm"},
+		{name: "Rain rate in si", quantity: units.PrecipitationRate, system: units.SI, input: 0.5, expected: 12.7, expectedSymbol: "mm/h"},
+		{name: "Dimensionless", quantity: units.None, system: units.SI, input: 42, expected: 42, expectedSymbol: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.quantity.Convert(tt.system, tt.input)
+			if math.Abs(got-tt.expected) > 0.01 {
+				t.Errorf("expected %f, got %f", tt.expected, got)
+			}
+
+			if symbol := tt.quantity.Symbol(tt.system); symbol != tt.expectedSymbol {
+				t.Errorf("expected symbol %q, got %q", tt.expectedSymbol, symbol)
+			}
+		})
+	}
+}
+
+func TestParseSystem(t *testing.T) {
+	system, err := units.ParseSystem("")
+	if err != nil || system != units.Imperial {
+		t.Errorf("expected imperial default, got %s (%v)", system, err)
+	}
+
+	system, err = units.ParseSystem("SI")
+	if err != nil || system != units.SI {
+		t.Errorf("expected si, got %s (%v)", system, err)
+	}
+
+	_, err = units.ParseSystem("furlongs")
+	if !errors.Is(err, units.ErrUnknownSystem) {
+		t.Errorf("expected %v, got %v", units.ErrUnknownSystem, err)
+	}
+}
+
+func TestConvertDifferenceAndSum(t *testing.T) {
+	// a 9°F spread is a 5°C spread
+	if got := units.Temperature.ConvertDifference(units.Metric, 9); math.Abs(got-5) > 0.01 {
+		t.Errorf("expected a difference of 5, got %f", got)
+	}
+
+	// 32°F + 212°F is 0°C + 100°C
+	if got := units.Temperature.ConvertSum(units.Metric, 244, 2); math.Abs(got-100) > 0.01 {
+		t.Errorf("expected a sum of 100, got %f", got)
+	}
+
+	if got := units.Precipitation.ConvertSum(units.Metric, 2, 10); math.Abs(got-50.8) > 0.01 {
+		t.Errorf("expected a sum of 50.8, got %f", got)
+	}
+}